@@ -0,0 +1,83 @@
+package godi
+
+import (
+	"reflect"
+	"sort"
+)
+
+// GetAll resolves every named and not named registration for T visible from the container.
+// Instances are returned ordered by name, with the not named registration first.
+func GetAll[T any](c *Container) ([]T, error) {
+	key := getKeyFromT[T]()
+
+	if err := checkClosed(c, key, ""); err != nil {
+		return nil, err
+	}
+
+	names := definitionNames(c, key)
+	result := make([]T, 0, len(names))
+
+	for _, name := range names {
+		value, err := resolve(c, key, name)
+		if err != nil {
+			return nil, err
+		}
+
+		instance, err := castTo[T](value)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, instance)
+	}
+
+	return result, nil
+}
+
+// GetMap resolves every named and not named registration for T indexed by their name
+func GetMap[T any](c *Container) (map[string]T, error) {
+	key := getKeyFromT[T]()
+
+	if err := checkClosed(c, key, ""); err != nil {
+		return nil, err
+	}
+
+	names := definitionNames(c, key)
+	result := make(map[string]T, len(names))
+
+	for _, name := range names {
+		value, err := resolve(c, key, name)
+		if err != nil {
+			return nil, err
+		}
+
+		result[name], err = castTo[T](value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// Returns the sorted names of the definitions for the type on the scopes and the global definitions
+func definitionNames(c *Container, key reflect.Type) []string {
+	names := c.globalDef.names(key)
+
+	for scope := c; scope != nil && scope.scopedDef != nil; scope = scope.parent {
+		names = append(names, scope.scopedDef.names(key)...)
+	}
+
+	sort.Strings(names)
+
+	// The same name can be registered on several scopes, overriding the parent registration
+	unique := names[:0]
+
+	for i, name := range names {
+		if i == 0 || name != names[i-1] {
+			unique = append(unique, name)
+		}
+	}
+
+	return unique
+}
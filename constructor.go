@@ -0,0 +1,127 @@
+package godi
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var ErrInvalidConstructor = errors.New("constructor must be a non variadic func returning T or (T, error)")
+
+var (
+	containerType = reflect.TypeOf((*Container)(nil))
+	errorType     = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// Provide registers a constructor like func(a A, b B) T or func(a A, b B) (T, error)
+// for its return type T. Every parameter is resolved from the container by type
+// when the instance is built, a *Container parameter receives the resolving container
+// as factories do, a copy tracking the registration being built.
+func Provide(c *Container, lifetime lifetime, ctor any) error {
+	return provide(c, "", lifetime, ctor, nil)
+}
+
+func ProvideNamed(c *Container, name string, lifetime lifetime, ctor any) error {
+	return provide(c, name, lifetime, ctor, nil)
+}
+
+// ProvideAs registers the constructor for T instead of its return type,
+// so a constructor returning *SomeStruct can satisfy an interface
+func ProvideAs[T any](c *Container, lifetime lifetime, ctor any) error {
+	return provide(c, "", lifetime, ctor, getKeyFromT[T]())
+}
+
+func ProvideNamedAs[T any](c *Container, name string, lifetime lifetime, ctor any) error {
+	return provide(c, name, lifetime, ctor, getKeyFromT[T]())
+}
+
+func provide(c *Container, name string, lifetime lifetime, ctor any, as reflect.Type) error {
+	fn := reflect.ValueOf(ctor)
+
+	if fn.Kind() != reflect.Func || fn.IsNil() {
+		return ErrInvalidConstructor
+	}
+
+	fnType := fn.Type()
+
+	if fnType.IsVariadic() || fnType.NumOut() == 0 || fnType.NumOut() > 2 {
+		return ErrInvalidConstructor
+	}
+
+	if fnType.NumOut() == 2 && fnType.Out(1) != errorType {
+		return ErrInvalidConstructor
+	}
+
+	key := fnType.Out(0)
+
+	if as != nil {
+		if !key.AssignableTo(as) {
+			return fmt.Errorf("%w: %v is not assignable to %v", ErrInvalidConstructor, key, as)
+		}
+
+		key = as
+	}
+
+	params := make([]reflect.Type, fnType.NumIn())
+	deps := make([]dependency, 0, len(params))
+
+	for i := range params {
+		params[i] = fnType.In(i)
+
+		if params[i] != containerType {
+			deps = append(deps, dependency{key: params[i]})
+		}
+	}
+
+	return addDefinition(c, key, name, &definition{
+		lifetime: lifetime,
+		deps:     deps,
+		factory: func(c *Container) (any, error) {
+			args := make([]reflect.Value, len(params))
+
+			for i, param := range params {
+				arg, err := resolveParam(c, param)
+				if err != nil {
+					return nil, paramError(i, param, err)
+				}
+
+				args[i] = arg
+			}
+
+			out := fn.Call(args)
+
+			if len(out) == 2 && !out[1].IsNil() {
+				err, ok := out[1].Interface().(error)
+				if !ok {
+					return nil, ErrInvalidConstructor
+				}
+
+				return nil, err
+			}
+
+			return out[0].Interface(), nil
+		},
+	})
+}
+
+func resolveParam(c *Container, param reflect.Type) (reflect.Value, error) {
+	if param == containerType {
+		return reflect.ValueOf(c), nil
+	}
+
+	value, err := resolve(c, param, "")
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	// A nil interface or pointer can't be converted with reflect.ValueOf
+	if value == nil {
+		return reflect.Zero(param), nil
+	}
+
+	return reflect.ValueOf(value), nil
+}
+
+func paramError(i int, param reflect.Type, err error) error {
+	return fmt.Errorf("resolving parameter %d of type %v: %w", i, param, err)
+}
@@ -2,148 +2,260 @@
 package godi
 
 import (
-	"errors"	
+	"context"
+	"errors"
+	"fmt"
 	"reflect"
-	"sync"
+	"sync/atomic"
 )
 
 var (
 	ErrDecoratedMustBeInterface = errors.New("item to decorate must be an interface")
 	ErrFactoryAlreadyRegistered = errors.New("factory already registered")
 	ErrFactoryNotRegistered     = errors.New("factory not registered")
-	ErrDecoratorBeforeFactory   = errors.New("a factory needs to be registered before a decorator")
+	ErrFactoryPanicked          = errors.New("factory panicked")
+	ErrTypeMismatch             = errors.New("instance doesn't match the requested type")
+	// Deprecated: decorators can be registered before the factories they decorate
+	ErrDecoratorBeforeFactory = errors.New("a factory needs to be registered before a decorator")
 )
 
 type Container struct {
-	globalDef      map[reflect.Type]map[string]*definition
-	scopedDef      map[reflect.Type]map[string]*definition
+	globalDef      *registry
+	scopedDef      *registry
 	singletonCache *lifetimeCache
 	scopedCache    *lifetimeCache
+	shared         *shared
+	// Scope the scope was created from, nil for the root container and its direct scopes
+	parent *Container
+	// Registration being built when the container is passed to a factory
+	resolving *resolution
+}
+
+// State shared by the container and all its scopes
+type shared struct {
+	captiveMode atomic.Int32
+	// Set once the container is frozen
+	frozen atomic.Pointer[frozenDefinitions]
 }
 
 type definition struct {
 	lifetime lifetime
 	/*
-		This is a slice of factories to contain the factory for the instance and the decorators
-		The decorators have the following definition: func(decorated T, c *Container) T
-		The item to create has the definition: func(c *Container) T
+		The factory creates the instance and the decorators of its type wrap it by order,
+		the ones with the same order in registration order.
+		Both are stored type erased, so a definition can be resolved by its reflect.Type
+		when T is not known at compile time, like the parameters of a constructor.
+		The typed versions have the following definitions:
+		The item to create: func(c *Container) (T, error)
+		The decorators: func(decorated T, c *Container) (T, error), as registered with DecorateE
+		Decorators are stored by type on the registry, so they apply to definitions registered later.
 	*/
-	f []any
+	factory func(c *Container) (any, error)
+	// Tags to select the definition on DecorateWhen
+	tags []string
+	// Decorators of the type compiled when frozen, as no decorators can be added afterwards
+	decorators []decorator
+	frozen     bool
+	// Registered with Instance, its cache entry is populated on registration on the container
+	instance bool
+	// Declared with DeclareScoped, to be registered on each scope
+	declared bool
+	// Declared with Inherit, resolved from the parent scope
+	inherited bool
+	// Scope the definition was registered on, nil for global definitions
+	owner *Container
+	// Dependencies declared by constructors, used for validation
+	deps []dependency
+	// Cache entry of a singleton, only set on frozen definitions
+	singleton *cacheEntry
+	// Index of the cache entry of a scoped definition on every scope, only set on frozen definitions
+	scopedIndex int
 }
 
-func New() *Container {
-	var singletonCache = lifetimeCache{
-		entries: make(map[reflect.Type]map[string]*cacheEntry),
-		mx:      sync.Mutex{},
-	}
+type dependency struct {
+	key      reflect.Type
+	name     string
+	optional bool
+}
 
-	var scopedCache = lifetimeCache{
-		entries: make(map[reflect.Type]map[string]*cacheEntry),
-		mx:      sync.Mutex{},
-	}
+type decoratorFunc func(decorated any, c *Container) (any, error)
 
+func New() *Container {
 	return &Container{
-		globalDef:      make(map[reflect.Type]map[string]*definition),
-		singletonCache: &singletonCache,
-		scopedCache:    &scopedCache,
+		globalDef:      &registry{},
+		singletonCache: &lifetimeCache{},
+		scopedCache:    &lifetimeCache{},
+		shared:         &shared{},
 	}
 }
 
 func Singleton[T any](c *Container, f func(c *Container) T) error {
-	return add(c, "", LifetimeSingleton, f)
+	return add(c, "", LifetimeSingleton, withNoError(f))
 }
 
 func Scoped[T any](c *Container, f func(c *Container) T) error {
-	return add(c, "", LifetimeScoped, f)
+	return add(c, "", LifetimeScoped, withNoError(f))
 }
 
 func Transient[T any](c *Container, f func(c *Container) T) error {
-	return add(c, "", LifetimeTransient, f)
+	return add(c, "", LifetimeTransient, withNoError(f))
 }
 
 func SingletonNamed[T any](c *Container, name string, f func(c *Container) T) error {
-	return add(c, name, LifetimeSingleton, f)
+	return add(c, name, LifetimeSingleton, withNoError(f))
 }
 
 func ScopedNamed[T any](c *Container, name string, f func(c *Container) T) error {
-	return add(c, name, LifetimeScoped, f)
+	return add(c, name, LifetimeScoped, withNoError(f))
 }
 
 func TransientNamed[T any](c *Container, name string, f func(c *Container) T) error {
+	return add(c, name, LifetimeTransient, withNoError(f))
+}
+
+// The E variants accept factories that can fail, the error is returned by Get
+// and failed singleton or scoped instances are not cached so they can be retried.
+func SingletonE[T any](c *Container, f func(c *Container) (T, error)) error {
+	return add(c, "", LifetimeSingleton, f)
+}
+
+func ScopedE[T any](c *Container, f func(c *Container) (T, error)) error {
+	return add(c, "", LifetimeScoped, f)
+}
+
+func TransientE[T any](c *Container, f func(c *Container) (T, error)) error {
+	return add(c, "", LifetimeTransient, f)
+}
+
+func SingletonNamedE[T any](c *Container, name string, f func(c *Container) (T, error)) error {
+	return add(c, name, LifetimeSingleton, f)
+}
+
+func ScopedNamedE[T any](c *Container, name string, f func(c *Container) (T, error)) error {
+	return add(c, name, LifetimeScoped, f)
+}
+
+func TransientNamedE[T any](c *Container, name string, f func(c *Container) (T, error)) error {
 	return add(c, name, LifetimeTransient, f)
 }
 
-func add[T any](c *Container, name string, lifetime lifetime, f func(c *Container) T) error {
-	factoryName := getKeyFromT[T]()
+// Instance registers an already built value with singleton semantics
+func Instance[T any](c *Container, v T) error {
+	return InstanceNamed(c, "", v)
+}
 
-	var typeDef map[string]*definition
-	foundTypeDef := false
+func InstanceNamed[T any](c *Container, name string, v T) error {
+	return addDefinition(c, getKeyFromT[T](), name, &definition{
+		lifetime: LifetimeSingleton,
+		factory: func(c *Container) (any, error) {
+			return v, nil
+		},
+		instance: true,
+	})
+}
 
-	// Search for existing type def in scoped def if exists or on the global map
-	if c.scopedDef != nil {
-		typeDef, foundTypeDef = c.scopedDef[factoryName]
+func withNoError[T any](f func(c *Container) T) func(c *Container) (T, error) {
+	return func(c *Container) (T, error) {
+		return f(c), nil
 	}
+}
 
-	if !foundTypeDef {
-		typeDef, foundTypeDef = c.globalDef[factoryName]
-	}
+func add[T any](c *Container, name string, lifetime lifetime, f func(c *Container) (T, error), deps ...dependency) error {
+	return addDefinition(c, getKeyFromT[T](), name, &definition{
+		lifetime: lifetime,
+		factory: func(c *Container) (any, error) {
+			return f(c)
+		},
+		deps: deps,
+	})
+}
 
-	// If a definition exist for the same type and name throw err
-	if foundTypeDef {
-		_, foundNamedDef := typeDef[name]
+func addDefinition(c *Container, key reflect.Type, name string, d *definition) error {
+	if !d.lifetime.valid() {
+		return fmt.Errorf("%w: %q", ErrInvalidLifetime, d.lifetime)
+	}
 
-		if foundNamedDef {
-			return ErrFactoryAlreadyRegistered
-		}
+	// Definitions registered on a scope go to scopedDef whatever their lifetime,
+	// so they are only visible to the scope and its nested scopes
+	if c.scopedDef != nil {
+		return addScopedDefinition(c, key, name, d)
 	}
 
-	// If typeDef not found create a new one in either scopedDef or globalDef
-	if !foundTypeDef {
-		typeDef = make(map[string]*definition)
+	c.globalDef.mx.Lock()
+	defer c.globalDef.mx.Unlock()
 
-		if lifetime == LifetimeScoped && c.scopedDef != nil {
-			c.scopedDef[factoryName] = typeDef
-		} else {
-			c.globalDef[factoryName] = typeDef
-		}
+	if c.shared.frozen.Load() != nil {
+		return ErrContainerFrozen
 	}
 
-	typeDef[name] = &definition{
-		lifetime: lifetime,
-		f:        []any{f},
+	// If a definition exist for the same type and name throw err
+	if _, found := c.globalDef.defs[key][name]; found {
+		return ErrFactoryAlreadyRegistered
 	}
 
+	c.globalDef.set(key, name, d)
+	populateInstance(c, key, name, d)
+
 	return nil
 }
 
-func Decorate[T any](c *Container, f func(decorated T, c *Container) T) error {
-	target := getKeyFromT[T]()
+// Scopes register definitions on every request, so only the read lock of the global definitions is taken
+// to check them, before the lock of the scope. Registrations on different scopes don't block each other.
+func addScopedDefinition(c *Container, key reflect.Type, name string, d *definition) error {
+	c.globalDef.mx.RLock()
+	defer c.globalDef.mx.RUnlock()
 
-	var typeDef map[string]*definition
-	foundTypeDef := false
+	c.scopedDef.mx.Lock()
+	defer c.scopedDef.mx.Unlock()
 
-	if c.scopedDef != nil {
-		typeDef, foundTypeDef = c.scopedDef[target]
+	// Global definitions can only be registered again on a scope
+	// if they were declared to be registered on the scope or are inherited from the parent scope
+	globalDef, foundGlobal := c.globalDef.defs[key][name]
+
+	if foundGlobal && !globalDef.declared && !d.inherited {
+		return ErrFactoryAlreadyRegistered
 	}
 
-	if !foundTypeDef {
-		typeDef, foundTypeDef = c.globalDef[target]
+	if _, found := c.scopedDef.defs[key][name]; found {
+		return ErrFactoryAlreadyRegistered
 	}
 
-	if !foundTypeDef {
-		return ErrDecoratorBeforeFactory
+	d.owner = c.withoutResolving()
+	c.scopedDef.set(key, name, d)
+
+	if foundGlobal && d.inherited {
+		c.scopedDef.overrides.Add(1)
 	}
 
-	if target.Elem().Kind() != reflect.Interface {
-		return ErrDecoratedMustBeInterface
+	return nil
+}
+
+// Instances have nothing to build, so the cache is populated straight away unless they need
+// to be decorated. Needs to be called holding the write lock of the global definitions,
+// so a concurrent Decorate either sees the instance to reset it or is seen by it.
+// Instances registered on a scope are built on their first Get instead, as decorators registered
+// later on the container or a parent scope don't reset the cache of the scope.
+func populateInstance(c *Container, key reflect.Type, name string, d *definition) {
+	if !d.instance || len(c.globalDef.decorators[key]) > 0 {
+		return
 	}
 
-	// For each registration for the type we add the decorated as we can have named definitions
-	for _, namedDef := range typeDef {
-		namedDef.f = append(namedDef.f, f)
+	value, err := d.factory(c)
+	if err != nil {
+		// Left to be built on the first Get, which reports the error
+		return
 	}
 
-	return nil
+	entry := c.singletonCache.entry(key, name)
+	entry.mx.Lock()
+	entry.store(value, value)
+	entry.mx.Unlock()
+}
+
+// Decorate wraps every instance of T, no matter if it was registered before or after the decorator,
+// so modules can be installed in any order. Use WithOrder to define which decorators wrap the others.
+func Decorate[T any](c *Container, f func(decorated T, c *Container) T, opts ...DecoratorOption) error {
+	return registerDecorator(c, nil, withNoDecoratorError(f), registrationSite(), opts)
 }
 
 func Get[T any](c *Container) (T, error) {
@@ -151,285 +263,411 @@ func Get[T any](c *Container) (T, error) {
 }
 
 func GetNamed[T any](c *Container, name string) (T, error) {
-	key := getKeyFromT[T]()
-
-	var typeDef map[string]*definition
-	foundTypeDef := false
+	var result T
 
-	if c.scopedDef != nil {
-		typeDef, foundTypeDef = c.scopedDef[key]
+	value, err := resolve(c, getKeyFromT[T](), name)
+	if err != nil {
+		return result, err
 	}
 
-	if !foundTypeDef {
-		typeDef, foundTypeDef = c.globalDef[key]
-	}
+	return castTo[T](value)
+}
+
+// GetUndecorated returns the instance of T as built by its factory, before being decorated.
+// It shares the cache of the decorated instance, so singletons and scoped instances are built once.
+func GetUndecorated[T any](c *Container) (T, error) {
+	return GetNamedUndecorated[T](c, "")
+}
 
-	if !foundTypeDef {
-		var result T
+func GetNamedUndecorated[T any](c *Container, name string) (T, error) {
+	var result T
 
-		return result, ErrFactoryNotRegistered
+	value, err := resolveInstance(c, getKeyFromT[T](), name, true)
+	if err != nil {
+		return result, err
 	}
 
-	if len(typeDef) == 0 {
-		var result T
+	return castTo[T](value)
+}
+
+func GetNoAlloc[T any](c *Container, x *T) error {
+	return GetNamedNoAlloc(c, x, "")
+}
 
-		return result, ErrFactoryNotRegistered
+func GetNamedNoAlloc[T any](c *Container, x *T, name string) error {
+	value, err := resolve(c, getKeyFromT[T](), name)
+	if err != nil {
+		return err
 	}
 
-	namedDef, foundNamedDef := typeDef[name]
+	*x, err = castTo[T](value)
+
+	return err
+}
 
-	if !foundNamedDef {
-		var result T
+// Resolves an instance for the type and name honoring the lifetime of its definition
+func resolve(c *Container, key reflect.Type, name string) (any, error) {
+	return resolveInstance(c, key, name, false)
+}
 
-		return result, ErrFactoryNotRegistered
+// Resolves the instance, or the instance before being decorated sharing its cache entry
+func resolveInstance(c *Container, key reflect.Type, name string, undecorated bool) (any, error) {
+	value, raw, err := resolveValues(c, key, name, undecorated)
+	if undecorated {
+		return raw, err
 	}
 
-	if namedDef.lifetime == LifetimeSingleton {
-		value, found := getFromCacheOrBuild[T](c, c.singletonCache, key, name, namedDef)
+	return value, err
+}
 
-		return value, found
+// Resolves the instance along with the instance before being decorated,
+// transients are not decorated when only the instance before being decorated is needed
+func resolveValues(c *Container, key reflect.Type, name string, undecorated bool) (any, any, error) {
+	err := checkClosed(c, key, name)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	if namedDef.lifetime == LifetimeScoped {
-		value, found := getFromCacheOrBuild[T](c, c.scopedCache, key, name, namedDef)
+	namedDef, err := lookup(c, key, name)
+	if err != nil {
+		return nil, nil, resolutionError(c, key, name, nil, err)
+	}
 
-		return value, found
+	err = checkCircularDependency(c, key, name)
+	if err != nil {
+		return nil, nil, resolutionError(c, key, name, namedDef, err)
 	}
 
-	return buildItem[T](c, namedDef), nil
-}
+	err = checkCaptiveDependency(c, key, name, namedDef)
+	if err != nil {
+		return nil, nil, err
+	}
 
-func GetNoAlloc[T any](c *Container, x *T) error {
-	return GetNamedNoAlloc(c, x, "")
-}
+	// Instances shared with other scopes are built without the decorators of the scope,
+	// which are applied afterwards
+	if namedDef.inherited {
+		value, raw, err := resolveInherited(c, key, name, undecorated)
+		if undecorated {
+			return raw, raw, err
+		}
 
-func GetNamedNoAlloc[T any](c *Container, x *T, name string) error {
-	key := getKeyFromT[T]()
+		return decorateShared(c, c.parent, key, name, namedDef, value, raw, err)
+	}
 
-	var typeDef map[string]*definition
-	foundTypeDef := false
+	if namedDef.lifetime == LifetimeSingleton && namedDef.owner != nil {
+		value, raw, err := resolveScopeSingleton(c, key, name, namedDef)
+		if undecorated {
+			return raw, raw, err
+		}
 
-	if c.scopedDef != nil {
-		typeDef, foundTypeDef = c.scopedDef[key]
+		return decorateShared(c, namedDef.owner, key, name, namedDef, value, raw, err)
 	}
 
-	if !foundTypeDef {
-		typeDef, foundTypeDef = c.globalDef[key]
-	}
+	if namedDef.lifetime == LifetimeSingleton {
+		entry := namedDef.singleton
+		if entry == nil {
+			entry = c.singletonCache.entry(key, name)
+		}
+
+		value, raw, err := getFromCacheOrBuild(c, c.singletonCache, entry, key, name, namedDef)
+		if undecorated {
+			return raw, raw, err
+		}
 
-	if !foundTypeDef {
-		return ErrFactoryNotRegistered
+		return decorateShared(c, nil, key, name, namedDef, value, raw, err)
 	}
 
-	if len(typeDef) == 0 {
-		return ErrFactoryNotRegistered
+	if namedDef.lifetime == LifetimeScoped {
+		return getFromCacheOrBuild(c, c.scopedCache, scopedEntry(c, key, name, namedDef), key, name, namedDef)
 	}
 
-	namedDef, foundNamedDef := typeDef[name]
+	if undecorated {
+		resolvingCont := c.resolvingFor(key, name, namedDef)
+		defer resolvingCont.resolving.finish()
+
+		raw, err := buildRaw(resolvingCont, key, name, namedDef)
 
-	if !foundNamedDef {
-		return ErrFactoryNotRegistered
+		return raw, raw, err
 	}
 
-	if namedDef.lifetime == LifetimeSingleton {
-		found := getFromCacheOrBuildNoAlloc(c, c.singletonCache, key, name, namedDef, x)
+	return buildItem(c, key, name, namedDef)
+}
 
-		return found
+// Once frozen, scoped definitions of the container use the entries created at once for the scope,
+// also when the definition is found on the registry because the scope inherits a global definition
+func scopedEntry(c *Container, key reflect.Type, name string, d *definition) *cacheEntry {
+	if d.frozen {
+		return c.scopedCache.frozenEntry(c.shared.frozen.Load(), d.scopedIndex)
 	}
 
-	if namedDef.lifetime == LifetimeScoped {
-		found := getFromCacheOrBuildNoAlloc(c, c.scopedCache, key, name, namedDef, x)
+	if frozen := c.shared.frozen.Load(); frozen != nil && d.owner == nil {
+		if frozenDef, found := frozen.defs[cacheKey{key: key, name: name}]; found && frozenDef.lifetime == LifetimeScoped {
+			return c.scopedCache.frozenEntry(frozen, frozenDef.scopedIndex)
+		}
+	}
 
-		return found
+	return c.scopedCache.entry(key, name)
+}
+
+// Resolutions are rejected once the container is shutdown or the scope is closed
+func checkClosed(c *Container, key reflect.Type, name string) error {
+	if c.singletonCache.closed.Load() {
+		return resolutionError(c, key, name, nil, ErrContainerShutdown)
 	}
 
-	buildItemNoAlloc(c, x, namedDef)
+	if c.scopedCache.closed.Load() {
+		return resolutionError(c, key, name, nil, ErrScopeClosed)
+	}
 
 	return nil
 }
 
-func (c *Container) NewScope() *Container {
-	var newScopedCache = lifetimeCache{
-		entries: make(map[reflect.Type]map[string]*cacheEntry),
-		mx:      sync.Mutex{},
+// Finds the definition for the type and name on the scope or the global definitions.
+// Once frozen the global definitions are found without taking any lock,
+// unless a scope inherits a global definition, only declared definitions
+// can be registered on a scope for the same type and name.
+func lookup(c *Container, key reflect.Type, name string) (*definition, error) {
+	if frozen := c.shared.frozen.Load(); frozen != nil && !c.overridesGlobal() {
+		if namedDef, found := frozen.defs[cacheKey{key: key, name: name}]; found && !namedDef.declared {
+			return namedDef, nil
+		}
 	}
 
-	return &Container{
+	// Scopes see the registrations of their parent scopes, the closest one wins
+	for scope := c; scope != nil && scope.scopedDef != nil; scope = scope.parent {
+		if namedDef, found := scope.scopedDef.get(key, name); found {
+			return namedDef, nil
+		}
+	}
+
+	if namedDef, found := c.globalDef.get(key, name); found && !namedDef.declared {
+		return namedDef, nil
+	}
+
+	return nil, ErrFactoryNotRegistered
+}
+
+// Creating a scope is a single allocation, its maps are only created when used.
+// A scope created from another scope sees the registrations of its parent and can override them,
+// scoped instances are cached per scope unless declared with Inherit.
+func (c *Container) NewScope() *Container {
+	scope := &struct {
+		cont      Container
+		scopedDef registry
+		cache     lifetimeCache
+	}{}
+
+	scope.cont = Container{
 		globalDef:      c.globalDef,
-		scopedDef:      make(map[reflect.Type]map[string]*definition),
+		scopedDef:      &scope.scopedDef,
 		singletonCache: c.singletonCache,
-		scopedCache:    &newScopedCache,
+		scopedCache:    &scope.cache,
+		shared:         c.shared,
+	}
+
+	if c.scopedDef != nil {
+		scope.cont.parent = c.withoutResolving()
 	}
+
+	return &scope.cont
 }
 
 // We use a thread safe from getting items from the cache or build new ones
-// There are 2 level caches one to lock the creation of containers for the type
-// and the second one for the instance itself
-// as it might need to resolved other dependencies and types.
-func getFromCacheOrBuild[T any](
+// Once built, instances are read without taking any lock, otherwise the lock of the entry
+// is held while building the instance, as it might need to resolve other dependencies and types
+// guaranteeing it is only built once even when requested concurrently.
+func getFromCacheOrBuild(
 	c *Container,
 	cache *lifetimeCache,
+	namedCache *cacheEntry,
 	key reflect.Type,
 	name string,
-	d *definition) (T, error) {
-	typeCache, found := cache.entries[key]
-
-	// If cache not found for the type create one
-	if !found {
-		cache.mx.Lock()
+	d *definition) (any, any, error) {
+	if value := namedCache.value.Load(); value != nil {
+		return value.instance, value.raw, nil
+	}
 
-		typeCache, found = cache.entries[key]
+	return buildEntry(c, cache, namedCache, key, name, d)
+}
 
-		if !found {
-			entry := make(map[string]*cacheEntry)
-			cache.entries[key] = entry
-			typeCache = entry
-		}
+// Builds the instance of the entry holding its lock,
+// which is released even if building the instance panics
+func buildEntry(
+	c *Container,
+	cache *lifetimeCache,
+	namedCache *cacheEntry,
+	key reflect.Type,
+	name string,
+	d *definition) (any, any, error) {
+	// Factories and decorators get a container tracking what is being built
+	resolvingCont := c.resolvingFor(key, name, d)
+	defer resolvingCont.resolving.finish()
 
-		cache.mx.Unlock()
+	err := namedCache.lockFor(resolvingCont.resolving)
+	if err != nil {
+		return nil, nil, resolutionError(c, key, name, d,
+			fmt.Errorf("%w: %v, being built by another goroutine waiting for it", err, c.resolving.path(key, name)))
 	}
 
-	// Now we search for the named cache in the existing type cache
-	namedCache, found := typeCache[name]
+	defer namedCache.unlock()
 
-	if !found {
-		cache.mx.Lock()
+	// Another goroutine might have built it while waiting for the lock
+	if value := namedCache.value.Load(); value != nil {
+		return value.instance, value.raw, nil
+	}
 
-		namedCache, found = typeCache[name]
+	value, raw, err := buildResolving(resolvingCont, key, name, d)
+	if err != nil {
+		// Leave the entry uninitialized so the next call can retry
+		return nil, nil, err
+	}
 
-		if !found {
-			entry := cacheEntry{}
-			typeCache[name] = &entry
-			namedCache = &entry
-		}
+	namedCache.store(value, raw)
+
+	// Instances are owned by whoever registered them, they are not disposed
+	if !d.instance && !cache.track(namedCache) {
+		// Closed while building, the instance was not collected to be disposed
+		err = dispose(context.Background(), namedCache)
+		namedCache.value.Store(nil)
 
-		cache.mx.Unlock()
+		return nil, nil, errors.Join(closedError(c, cache), err)
 	}
 
-	if !namedCache.initialized {
-		namedCache.mx.Lock()
+	return value, raw, nil
+}
 
-		if !namedCache.initialized {
-			namedCache.initialized = true
-			namedCache.instance = buildItem[T](c, d)
-		}
+// Builds the instance, returning it along with the instance before being decorated
+func buildItem(c *Container, key reflect.Type, name string, d *definition) (any, any, error) {
+	// Factories and decorators get a container tracking what is being built
+	c = c.resolvingFor(key, name, d)
+	defer c.resolving.finish()
 
-		namedCache.mx.Unlock()
+	return buildResolving(c, key, name, d)
+}
+
+// Builds the instance with the container tracking the registration being built
+func buildResolving(c *Container, key reflect.Type, name string, d *definition) (any, any, error) {
+	raw, err := buildRaw(c, key, name, d)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	val, ok := namedCache.instance.(T)
-	if !ok {
-		panic("Couldn't cast the type")
+	value, err := decorate(c, key, name, raw, definitionDecorators(c, key, name, d))
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return val, nil
+	return value, raw, nil
 }
 
-// We use a thread safe from getting items from the cache or build new ones
-// There are 2 level caches one to lock the creation of containers for the type
-// and the second one for the instance itself
-// as it might need to resolved other dependencies and types.
-func getFromCacheOrBuildNoAlloc[T any](
-	c *Container,
-	cache *lifetimeCache,
-	key reflect.Type,
-	name string,
-	d *definition,
-	x *T) error {
-	typeCache, found := cache.entries[key]
+func buildRaw(c *Container, key reflect.Type, name string, d *definition) (any, error) {
+	raw, err := callFactory(c, d)
+	if err != nil {
+		return nil, buildError(key, name, err)
+	}
 
-	// If cache not found for the type create one
-	if !found {
-		cache.mx.Lock()
+	return raw, nil
+}
 
-		typeCache, found = cache.entries[key]
+// Factories and decorators panicking are reported as errors, so the cache entry is left uninitialized
+// and the next call can retry. Panics raised on purpose by CaptiveDependencyPanic keep panicking.
+func recoverPanic(err *error) {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
 
-		if !found {
-			entry := make(map[string]*cacheEntry)
-			cache.entries[key] = entry
-			typeCache = entry
-		}
+	recoveredErr, ok := recovered.(error)
+	if !ok {
+		*err = fmt.Errorf("%w: %v", ErrFactoryPanicked, recovered)
 
-		cache.mx.Unlock()
+		return
 	}
 
-	// Now we search for the named cache in the existing type cache
-	namedCache, found := typeCache[name]
-
-	if !found {
-		cache.mx.Lock()
+	if errors.Is(recoveredErr, ErrCaptiveDependency) {
+		panic(recovered)
+	}
 
-		namedCache, found = typeCache[name]
+	*err = fmt.Errorf("%w: %w", ErrFactoryPanicked, recoveredErr)
+}
 
-		if !found {
-			entry := cacheEntry{}
-			typeCache[name] = &entry
-			namedCache = &entry
-		}
+func callFactory(c *Container, d *definition) (value any, err error) {
+	defer recoverPanic(&err)
 
-		cache.mx.Unlock()
-	}
+	return d.factory(c)
+}
 
-	if !namedCache.initialized {
-		namedCache.mx.Lock()
+func callDecorator(c *Container, dec decorator, decorated any) (value any, err error) {
+	defer recoverPanic(&err)
 
-		if !namedCache.initialized {
-			namedCache.initialized = true
-			buildItemNoAlloc(c, x, d)
-			namedCache.instance = *x
-		}
+	return dec.f(decorated, c)
+}
 
-		namedCache.mx.Unlock()
-	}
+// Returns the decorators of the container for the type followed by the ones of the scopes,
+// leaving out the ones not applying to the definition
+func definitionDecorators(c *Container, key reflect.Type, name string, d *definition) []decorator {
+	decorators := globalDecorators(c, key, d)
 
-	val, ok := namedCache.instance.(T)
-	*x = val
-	if !ok {
-		panic("Couldn't cast the type")
+	// Singletons of the container are shared by every scope, so they are decorated on resolution
+	if d.lifetime != LifetimeSingleton || d.owner != nil {
+		decorators = mergeDecorators(decorators, scopeDecorators(c, nil, key))
 	}
 
-	return nil
+	return applicable(decorators, name, d)
 }
 
-func buildItem[T any](c *Container, d *definition) T {
-	factory, ok := d.f[0].(func(c *Container) T)
-	if !ok {
-		panic("factory doesn't match the expected format")
+func globalDecorators(c *Container, key reflect.Type, d *definition) []decorator {
+	if d.frozen {
+		return d.decorators
 	}
 
-	value := factory(c)
+	return c.globalDef.decoratorsFor(key)
+}
 
-	for i := 1; i <= len(d.f)-1; i++ {
-		decoratorF, ok := d.f[i].(func(decorated T, c *Container) T)
-		if !ok {
-			panic("Couldn't cast the decorator factory")
-		}
+func decorate(c *Container, key reflect.Type, name string, value any, decorators []decorator) (any, error) {
+	for _, decorator := range decorators {
+		var err error
 
-		value = decoratorF(value, c)
+		value, err = callDecorator(c, decorator, value)
+		if err != nil {
+			return nil, buildError(key, name, fmt.Errorf("decorator registered at %v: %w", decorator.site, err))
+		}
 	}
 
-	return value
+	return value, nil
 }
 
-func buildItemNoAlloc[T any](c *Container, x *T, d *definition) {
-	factory, ok := d.f[0].(func(c *Container) T)
+// Casts a resolved instance back to T, nil values are returned as the zero value of T
+// as that is what a factory returning a nil interface or pointer produced
+func castTo[T any](value any) (T, error) {
+	var result T
+
+	if value == nil {
+		return result, nil
+	}
+
+	result, ok := value.(T)
 	if !ok {
-		panic("factory doesn't match the expected format")
+		return result, fmt.Errorf("%w: expecting %v, got %T", ErrTypeMismatch, getKeyFromT[T](), value)
 	}
 
-	val := factory(c)
+	return result, nil
+}
 
-	for i := 1; i <= len(d.f)-1; i++ {
-		decoratorF, ok := d.f[i].(func(decorated T, c *Container) T)
-		if !ok {
-			panic("Couldn't cast the decorator factory")
-		}
+// Wraps a factory error with the type and name being built
+func buildError(key reflect.Type, name string, err error) error {
+	return fmt.Errorf("failed to build %v: %w", describe(key, name), err)
+}
 
-		val = decoratorF(val, c)
+// Describes a registration by its type and name for error messages
+func describe(key reflect.Type, name string) string {
+	if name == "" {
+		return key.String()
 	}
-	*x = val
+
+	return fmt.Sprintf("%v named %q", key, name)
 }
 
 func getKeyFromT[T any]() reflect.Type {
-	return reflect.TypeOf((*T)(nil))
+	return reflect.TypeOf((*T)(nil)).Elem()
 }
@@ -0,0 +1,254 @@
+package godi
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+)
+
+// Registration describes the definition being decorated, so DecorateWhen can select it
+type Registration struct {
+	Name     string
+	Lifetime lifetime
+	Tags     []string
+}
+
+type decorator struct {
+	f decoratorFunc
+	// Selects the definitions to decorate, nil for all of them
+	when  func(r Registration) bool
+	order int
+	// Allows decorating function and pointer types
+	nonInterface bool
+	// File and line where the decorator was registered, to report its errors
+	site string
+}
+
+type DecoratorOption func(d *decorator)
+
+// AllowNonInterface allows decorating function types, like http.HandlerFunc, and pointer types.
+// A pointer decorator receives the shared instance, modifying it instead of returning a new one
+// affects everyone using it, including the ones not decorated like other scopes.
+func AllowNonInterface() DecoratorOption {
+	return func(d *decorator) {
+		d.nonInterface = true
+	}
+}
+
+// WithOrder sets the order of the decorator, decorators with a higher order wrap the ones with a lower order,
+// so the highest order is the outermost decorator. Decorators with the same order are applied in registration
+// order, the default order is 0.
+func WithOrder(order int) DecoratorOption {
+	return func(d *decorator) {
+		d.order = order
+	}
+}
+
+// DecorateNamed wraps the instances of T registered with the name
+func DecorateNamed[T any](c *Container, name string, f func(decorated T, c *Container) T, opts ...DecoratorOption) error {
+	return registerDecorator(c, func(r Registration) bool {
+		return r.Name == name
+	}, withNoDecoratorError(f), registrationSite(), opts)
+}
+
+// DecorateWhen wraps the instances of T whose registration matches the predicate,
+// like the ones tagged with Tag
+func DecorateWhen[T any](
+	c *Container,
+	predicate func(r Registration) bool,
+	f func(decorated T, c *Container) T,
+	opts ...DecoratorOption) error {
+	return registerDecorator(c, predicate, withNoDecoratorError(f), registrationSite(), opts)
+}
+
+// DecorateE accepts decorators that can fail, the error is returned by Get
+// along with the file and line where the decorator was registered
+func DecorateE[T any](c *Container, f func(decorated T, c *Container) (T, error), opts ...DecoratorOption) error {
+	return registerDecorator(c, nil, f, registrationSite(), opts)
+}
+
+func withNoDecoratorError[T any](f func(decorated T, c *Container) T) func(decorated T, c *Container) (T, error) {
+	return func(decorated T, c *Container) (T, error) {
+		return f(decorated, c), nil
+	}
+}
+
+// Returns the file and line of the caller of the exported function registering a decorator
+func registrationSite() string {
+	_, file, line, ok := runtime.Caller(2)
+	if !ok {
+		return "unknown"
+	}
+
+	return fmt.Sprintf("%v:%v", file, line)
+}
+
+func registerDecorator[T any](
+	c *Container,
+	predicate func(r Registration) bool,
+	f func(decorated T, c *Container) (T, error),
+	site string,
+	opts []DecoratorOption) error {
+	target := getKeyFromT[T]()
+
+	dec := decorator{
+		f: func(decorated any, c *Container) (any, error) {
+			value, err := castTo[T](decorated)
+			if err != nil {
+				return nil, err
+			}
+
+			return f(value, c)
+		},
+		when: predicate,
+		site: site,
+	}
+
+	for _, opt := range opts {
+		opt(&dec)
+	}
+
+	if !decoratable(target, dec) {
+		return ErrDecoratedMustBeInterface
+	}
+
+	// Decorators registered on a scope only apply to the scope and its nested scopes,
+	// the definitions are left untouched so the decorator is not applied to other scopes
+	if c.scopedDef != nil {
+		return addDecorator(c, c.scopedDef, c.scopedCache, target, dec)
+	}
+
+	return addDecorator(c, c.globalDef, c.singletonCache, target, dec)
+}
+
+// Adds the decorator to the registry. Instances are cached undecorated on registration,
+// so their entries are cleared holding the lock of the registry, for the next Get to apply the decorator.
+// An instance might be being built with the previous decorators, it is reset again once the lock
+// is released, as building it might need the lock.
+func addDecorator(c *Container, r *registry, cache *lifetimeCache, key reflect.Type, dec decorator) error {
+	var instances []*cacheEntry
+
+	r.mx.Lock()
+
+	if r == c.globalDef && c.shared.frozen.Load() != nil {
+		r.mx.Unlock()
+
+		return ErrContainerFrozen
+	}
+
+	r.addDecorator(key, dec)
+
+	for name, namedDef := range r.defs[key] {
+		if namedDef.instance {
+			entry := cache.entry(key, name)
+			entry.value.Store(nil)
+			instances = append(instances, entry)
+		}
+	}
+
+	r.mx.Unlock()
+
+	for _, entry := range instances {
+		entry.reset()
+	}
+
+	return nil
+}
+
+func decoratable(target reflect.Type, dec decorator) bool {
+	switch target.Kind() {
+	case reflect.Interface:
+		return true
+	case reflect.Func, reflect.Pointer:
+		return dec.nonInterface
+	default:
+		return false
+	}
+}
+
+// Tag adds tags to the registration of T, to be selected by DecorateWhen.
+// Only registrations made on the same container or scope can be tagged.
+func Tag[T any](c *Container, tags ...string) error {
+	return TagNamed[T](c, "", tags...)
+}
+
+func TagNamed[T any](c *Container, name string, tags ...string) error {
+	key := getKeyFromT[T]()
+
+	r := c.globalDef
+	if c.scopedDef != nil {
+		r = c.scopedDef
+	}
+
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	if r == c.globalDef && c.shared.frozen.Load() != nil {
+		return ErrContainerFrozen
+	}
+
+	namedDef, found := r.defs[key][name]
+	if !found {
+		return ErrFactoryNotRegistered
+	}
+
+	// Definitions are replaced with a copy, as they might being used by a concurrent Get
+	taggedDef := *namedDef
+	taggedDef.tags = make([]string, 0, len(namedDef.tags)+len(tags))
+	taggedDef.tags = append(taggedDef.tags, namedDef.tags...)
+	taggedDef.tags = append(taggedDef.tags, tags...)
+	r.defs[key][name] = &taggedDef
+
+	return nil
+}
+
+// Returns the decorators applying to the definition,
+// the same slice is returned when all of them apply to avoid allocating
+func applicable(decorators []decorator, name string, d *definition) []decorator {
+	var result []decorator
+
+	for i, dec := range decorators {
+		matches := dec.when == nil || dec.when(Registration{Name: name, Lifetime: d.lifetime, Tags: d.tags})
+
+		switch {
+		case matches && result != nil:
+			result = append(result, dec)
+		case !matches && result == nil:
+			result = make([]decorator, i, len(decorators))
+			copy(result, decorators[:i])
+		}
+	}
+
+	if result == nil {
+		return decorators
+	}
+
+	return result
+}
+
+// Merges two lists of decorators sorted by order, for the same order the ones of a go first
+func mergeDecorators(a, b []decorator) []decorator {
+	if len(b) == 0 {
+		return a
+	}
+
+	if len(a) == 0 {
+		return b
+	}
+
+	result := make([]decorator, 0, len(a)+len(b))
+
+	for len(a) > 0 && len(b) > 0 {
+		if b[0].order < a[0].order {
+			result = append(result, b[0])
+			b = b[1:]
+		} else {
+			result = append(result, a[0])
+			a = a[1:]
+		}
+	}
+
+	result = append(result, a...)
+
+	return append(result, b...)
+}
@@ -0,0 +1,123 @@
+package godi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var (
+	ErrScopeClosed       = errors.New("scope is closed")
+	ErrContainerShutdown = errors.New("container is shutdown")
+	ErrShutdownOnScope   = errors.New("shutdown can only be called on the root container")
+	ErrCloseOnContainer  = errors.New("close can only be called on a scope, use shutdown for the root container")
+)
+
+// Disposer can be implemented by instances that need a context to release their resources,
+// it takes precedence over io.Closer when an instance implements both
+type Disposer interface {
+	Dispose(ctx context.Context) error
+}
+
+// Close disposes every scoped instance built on the scope in reverse creation order,
+// so instances are disposed before their dependencies. Further calls to Get on the scope
+// return ErrScopeClosed, calling Close again does nothing. The root container returns ErrCloseOnContainer.
+func (c *Container) Close() error {
+	if c.scopedDef == nil {
+		return ErrCloseOnContainer
+	}
+
+	if !c.scopedCache.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+
+	return disposeAll(context.Background(), c.scopedCache)
+}
+
+// Shutdown disposes every singleton built by the container in reverse creation order.
+// If the context is done before finishing, the instances not disposed are reported in the error.
+// Further calls to Get return ErrContainerShutdown, calling Shutdown again returns the same result.
+func (c *Container) Shutdown(ctx context.Context) error {
+	if c.scopedDef != nil {
+		return ErrShutdownOnScope
+	}
+
+	c.singletonCache.disposeOnce.Do(func() {
+		c.singletonCache.closed.Store(true)
+		c.singletonCache.disposeErr = disposeAll(ctx, c.singletonCache)
+	})
+
+	return c.singletonCache.disposeErr
+}
+
+func closedError(c *Container, cache *lifetimeCache) error {
+	if cache == c.singletonCache {
+		return ErrContainerShutdown
+	}
+
+	return ErrScopeClosed
+}
+
+func disposeAll(ctx context.Context, cache *lifetimeCache) error {
+	cache.mx.Lock()
+	built := cache.built
+	cache.built = nil
+	cache.mx.Unlock()
+
+	var errs []error
+
+	for i := len(built) - 1; i >= 0; i-- {
+		if ctx.Err() != nil {
+			return errors.Join(append(errs, notDisposedError(ctx, built[:i+1]))...)
+		}
+
+		done := make(chan error, 1)
+
+		go func(entry *cacheEntry) {
+			done <- dispose(ctx, entry)
+		}(built[i])
+
+		select {
+		case err := <-done:
+			if err != nil {
+				errs = append(errs, err)
+			}
+		case <-ctx.Done():
+			return errors.Join(append(errs, notDisposedError(ctx, built[:i+1]))...)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Reports the entries not disposed before the context was done, in disposal order
+func notDisposedError(ctx context.Context, entries []*cacheEntry) error {
+	pending := make([]string, 0, len(entries))
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		pending = append(pending, describe(entries[i].key, entries[i].name))
+	}
+
+	return fmt.Errorf("%w, not disposed: %s", ctx.Err(), strings.Join(pending, ", "))
+}
+
+func dispose(ctx context.Context, entry *cacheEntry) error {
+	var err error
+
+	instance, _ := entry.load()
+
+	switch instance := instance.(type) {
+	case Disposer:
+		err = instance.Dispose(ctx)
+	case io.Closer:
+		err = instance.Close()
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to dispose %v: %w", describe(entry.key, entry.name), err)
+	}
+
+	return nil
+}
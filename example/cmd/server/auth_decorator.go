@@ -0,0 +1,25 @@
+package main
+
+import (
+	"net/http"
+)
+
+type AuthDecorator struct {
+	handler http.Handler
+}
+
+func NewAuthDecorator(f http.Handler) http.Handler {
+	return AuthDecorator{
+		handler: f,
+	}
+}
+
+// ServeHTTP implements http.Handler
+func (d AuthDecorator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") == "" {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	d.handler.ServeHTTP(w, r)
+}
@@ -1,47 +1,37 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/mingue/godi"
 	"github.com/mingue/godi/example/pkg/invoice"
 )
 
+const (
+	rootPath  = "/"
+	readyPath = "/ready"
+)
+
 func main() {
 	initServer()
 }
 
-func initServer() {
-	log.Printf("Starting execution...")
-
-	// Create Container
-	cont := godi.New()
-
-	// Register dependencies
-	rootPath := "/"
-	readyPath := "/ready"
-
-	godi.ScopedNamed(cont, rootPath, func(c *godi.Container) http.Handler {
-		svc, _ := godi.Get[invoice.InvoiceService](c)
-		return invoice.NewInvoiceHandler(svc)
-	})
-
-	godi.ScopedNamed(cont, readyPath, func(c *godi.Container) http.Handler {
-		requestContext, _ := godi.Get[invoice.RequestContext](c)
-		return invoice.NewReadyHandler(requestContext)
-	})
+// Registers the dependencies of the server, tested on its own so broken wiring fails CI
+func register(cont *godi.Container) {
+	// The request context is registered on every request scope
+	godi.DeclareScoped[invoice.RequestContext](cont)
 
-	godi.Scoped(cont, func(c *godi.Container) invoice.InvoiceRepository {
-		requestContext, _ := godi.Get[invoice.RequestContext](c)
-		return invoice.NewInvoiceRepositoryImpl(requestContext)
-	})
+	// Handlers get their tagged fields injected, no constructor needed
+	godi.AutoWireNamedAs[http.Handler, *invoice.GetInvoicesHandler](cont, rootPath, godi.LifetimeScoped)
+	godi.AutoWireNamedAs[http.Handler, *invoice.ReadyHandler](cont, readyPath, godi.LifetimeScoped)
 
-	godi.Scoped(cont, func(c *godi.Container) invoice.InvoiceService {
-		repo, _ := godi.Get[invoice.InvoiceRepository](c)
-		return invoice.NewInvoiceServiceImpl(repo)
-	})
+	// Constructors get their parameters resolved from the container
+	godi.ProvideAs[invoice.InvoiceRepository](cont, godi.LifetimeScoped, invoice.NewInvoiceRepositoryImpl)
+	godi.ProvideAs[invoice.InvoiceService](cont, godi.LifetimeScoped, invoice.NewInvoiceServiceImpl)
 
 	godi.Scoped(cont, func(c *godi.Container) *log.Logger {
 		return log.New(os.Stdout, "App: ", log.Default().Flags())
@@ -52,11 +42,38 @@ func initServer() {
 		return NewRequestLoggingDecorator(d, logger)
 	})
 
+	// Only the invoices require authorization
+	godi.DecorateNamed(cont, rootPath, func(d http.Handler, c *godi.Container) http.Handler {
+		return NewAuthDecorator(d)
+	})
+}
+
+func initServer() {
+	log.Printf("Starting execution...")
+
+	// Create Container
+	cont := godi.New()
+
+	// Register dependencies
+	register(cont)
+
+	// Report every missing, circular or captive dependency at once before serving requests
+	if err := cont.Validate(); err != nil {
+		log.Fatalf("Invalid registrations: %v", err)
+	}
+
+	// Freeze the registrations, so resolving instances doesn't take any lock
+	if err := cont.Freeze(); err != nil {
+		log.Fatalf("Failed to freeze the container: %v", err)
+	}
+
 	// Register http handlers
 	rootCounter := 0
 	http.HandleFunc(rootPath, func(w http.ResponseWriter, r *http.Request) {
 		// Create a new container with scope for the http request
+		// closing it disposes the scoped instances created during the request
 		requestCont := cont.NewScope()
+		defer requestCont.Close()
 
 		rootCounter++
 
@@ -68,7 +85,12 @@ func initServer() {
 
 		// When you get an object from the container, it can have dependencies on the http request scoped dependencies registered above
 		// There is no need to pass the objects across the stack, can be injected to any object
-		h, _ := godi.GetNamed[http.Handler](requestCont, rootPath)
+		h, err := godi.GetNamed[http.Handler](requestCont, rootPath)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
 		h.ServeHTTP(w, r)
 	})
 
@@ -76,6 +98,7 @@ func initServer() {
 
 	http.HandleFunc(readyPath, func(w http.ResponseWriter, r *http.Request) {
 		requestCont := cont.NewScope()
+		defer requestCont.Close()
 
 		readyCounter++
 
@@ -84,11 +107,26 @@ func initServer() {
 			return invoice.RequestContext{SomeValue: "On ready path", UserAgent: userAgent, Counter: readyCounter}
 		})
 
-		h, _ := godi.GetNamed[http.Handler](requestCont, readyPath)
+		h, err := godi.GetNamed[http.Handler](requestCont, readyPath)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
 		h.ServeHTTP(w, r)
 	})
 
+	// Dispose the singletons once the server stops
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := cont.Shutdown(ctx); err != nil {
+			log.Printf("Failed to shutdown the container: %v", err)
+		}
+	}()
+
 	// Start the http server
 	log.Printf("Listening on port :8080")
-	log.Fatal(http.ListenAndServe(":8080", nil))
+	log.Print(http.ListenAndServe(":8080", nil))
 }
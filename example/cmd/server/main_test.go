@@ -0,0 +1,16 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/mingue/godi"
+)
+
+func TestRegistrationsAreValid(t *testing.T) {
+	cont := godi.New()
+	register(cont)
+
+	if err := cont.Validate(); err != nil {
+		t.Fatalf("Invalid registrations: %v", err)
+	}
+}
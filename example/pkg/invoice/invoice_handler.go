@@ -7,22 +7,16 @@ import (
 
 var _ http.Handler = &GetInvoicesHandler{}
 
-type GetInvoicesHandler struct{
-	service InvoiceService
+type GetInvoicesHandler struct {
+	Service InvoiceService `godi:""`
 }
 
-func NewInvoiceHandler(service InvoiceService) *GetInvoicesHandler {
-	return &GetInvoicesHandler{
-		service: service,
-	}
-}
-
-func (h *GetInvoicesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request){
-	invoices := h.service.GetAll()
+func (h *GetInvoicesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	invoices := h.Service.GetAll()
 	body, _ := json.Marshal(invoices)
 	w.Write(body)
 }
 
 func (h *GetInvoicesHandler) SomethingSilly() {
 
-}
\ No newline at end of file
+}
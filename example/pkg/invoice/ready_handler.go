@@ -6,14 +6,10 @@ import (
 )
 
 type ReadyHandler struct {
-	requestContext RequestContext
-}
-
-func NewReadyHandler(requestContext RequestContext) *ReadyHandler {
-	return &ReadyHandler{requestContext: requestContext}
+	RequestContext RequestContext `godi:""`
 }
 
 func (r *ReadyHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	log.Printf("Printing value: %v, User Agent: %v, Request count: %v", r.requestContext.SomeValue, r.requestContext.UserAgent, r.requestContext.Counter)
+	log.Printf("Printing value: %v, User Agent: %v, Request count: %v", r.RequestContext.SomeValue, r.RequestContext.UserAgent, r.RequestContext.Counter)
 	w.Write([]byte("ok"))
 }
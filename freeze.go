@@ -0,0 +1,81 @@
+package godi
+
+import (
+	"errors"
+)
+
+var (
+	ErrContainerFrozen = errors.New("container is frozen")
+	ErrFreezeOnScope   = errors.New("freeze can only be called on the root container")
+)
+
+// Immutable lookup of the global definitions, compiled when the container is frozen
+type frozenDefinitions struct {
+	defs map[cacheKey]*definition
+	// Scoped definitions by their index, to create the cache entries of a scope at once
+	scoped []cacheKey
+}
+
+// DeclareScoped declares that T will be registered on every scope, like a request context,
+// so registrations on the container can depend on it and be validated before any scope exists.
+// Resolving T on a scope where it has not been registered returns ErrFactoryNotRegistered.
+func DeclareScoped[T any](c *Container) error {
+	return DeclareScopedNamed[T](c, "")
+}
+
+func DeclareScopedNamed[T any](c *Container, name string) error {
+	return addDefinition(c, getKeyFromT[T](), name, &definition{
+		lifetime: LifetimeScoped,
+		declared: true,
+	})
+}
+
+// Freeze validates the registrations like Validate and compiles them into an immutable lookup,
+// so resolving instances doesn't take any lock beyond building singletons and scoped instances for the first time.
+// Registrations and decorators for the container return ErrContainerFrozen afterwards,
+// scopes can still register their own scoped definitions.
+func (c *Container) Freeze() error {
+	if c.scopedDef != nil {
+		return ErrFreezeOnScope
+	}
+
+	c.globalDef.mx.Lock()
+	defer c.globalDef.mx.Unlock()
+
+	if c.shared.frozen.Load() != nil {
+		return nil
+	}
+
+	err := validate(c, snapshot(nil, c.globalDef))
+	if err != nil {
+		return err
+	}
+
+	frozen := &frozenDefinitions{
+		defs: make(map[cacheKey]*definition),
+	}
+
+	for key, typeDef := range c.globalDef.defs {
+		for name, namedDef := range typeDef {
+			frozenDef := *namedDef
+			frozenDef.decorators = applicable(c.globalDef.decorators[key], name, namedDef)
+			frozenDef.frozen = true
+
+			// Singletons keep a reference to their cache entry to skip looking it up,
+			// scoped definitions the index of their entry on the cache of every scope
+			switch {
+			case frozenDef.lifetime == LifetimeSingleton:
+				frozenDef.singleton = c.singletonCache.entry(key, name)
+			case frozenDef.lifetime == LifetimeScoped && !frozenDef.declared:
+				frozenDef.scopedIndex = len(frozen.scoped)
+				frozen.scoped = append(frozen.scoped, cacheKey{key: key, name: name})
+			}
+
+			frozen.defs[cacheKey{key: key, name: name}] = &frozenDef
+		}
+	}
+
+	c.shared.frozen.Store(frozen)
+
+	return nil
+}
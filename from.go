@@ -0,0 +1,243 @@
+package godi
+
+// The From variants register plain functions as factories for T, every parameter
+// is resolved from the container with Get keeping compile time typing without reflection.
+// Errors resolving a parameter are returned from Get for T.
+
+func SingletonFrom1[A, T any](c *Container, f func(p1 A) T) error {
+	return add(c, "", LifetimeSingleton, from1[A, T](f), dependencyOf[A]())
+}
+
+func ScopedFrom1[A, T any](c *Container, f func(p1 A) T) error {
+	return add(c, "", LifetimeScoped, from1[A, T](f), dependencyOf[A]())
+}
+
+func TransientFrom1[A, T any](c *Container, f func(p1 A) T) error {
+	return add(c, "", LifetimeTransient, from1[A, T](f), dependencyOf[A]())
+}
+
+func SingletonFrom2[A, B, T any](c *Container, f func(p1 A, p2 B) T) error {
+	return add(c, "", LifetimeSingleton, from2[A, B, T](f), dependencyOf[A](), dependencyOf[B]())
+}
+
+func ScopedFrom2[A, B, T any](c *Container, f func(p1 A, p2 B) T) error {
+	return add(c, "", LifetimeScoped, from2[A, B, T](f), dependencyOf[A](), dependencyOf[B]())
+}
+
+func TransientFrom2[A, B, T any](c *Container, f func(p1 A, p2 B) T) error {
+	return add(c, "", LifetimeTransient, from2[A, B, T](f), dependencyOf[A](), dependencyOf[B]())
+}
+
+func SingletonFrom3[A, B, C, T any](c *Container, f func(p1 A, p2 B, p3 C) T) error {
+	return add(c, "", LifetimeSingleton, from3[A, B, C, T](f), dependencyOf[A](), dependencyOf[B](), dependencyOf[C]())
+}
+
+func ScopedFrom3[A, B, C, T any](c *Container, f func(p1 A, p2 B, p3 C) T) error {
+	return add(c, "", LifetimeScoped, from3[A, B, C, T](f), dependencyOf[A](), dependencyOf[B](), dependencyOf[C]())
+}
+
+func TransientFrom3[A, B, C, T any](c *Container, f func(p1 A, p2 B, p3 C) T) error {
+	return add(c, "", LifetimeTransient, from3[A, B, C, T](f), dependencyOf[A](), dependencyOf[B](), dependencyOf[C]())
+}
+
+func SingletonFrom4[A, B, C, D, T any](c *Container, f func(p1 A, p2 B, p3 C, p4 D) T) error {
+	return add(c, "", LifetimeSingleton, from4[A, B, C, D, T](f), dependencyOf[A](), dependencyOf[B](), dependencyOf[C](), dependencyOf[D]())
+}
+
+func ScopedFrom4[A, B, C, D, T any](c *Container, f func(p1 A, p2 B, p3 C, p4 D) T) error {
+	return add(c, "", LifetimeScoped, from4[A, B, C, D, T](f), dependencyOf[A](), dependencyOf[B](), dependencyOf[C](), dependencyOf[D]())
+}
+
+func TransientFrom4[A, B, C, D, T any](c *Container, f func(p1 A, p2 B, p3 C, p4 D) T) error {
+	return add(c, "", LifetimeTransient, from4[A, B, C, D, T](f), dependencyOf[A](), dependencyOf[B](), dependencyOf[C](), dependencyOf[D]())
+}
+
+func SingletonFrom5[A, B, C, D, E, T any](c *Container, f func(p1 A, p2 B, p3 C, p4 D, p5 E) T) error {
+	return add(c, "", LifetimeSingleton, from5[A, B, C, D, E, T](f), dependencyOf[A](), dependencyOf[B](), dependencyOf[C](), dependencyOf[D](), dependencyOf[E]())
+}
+
+func ScopedFrom5[A, B, C, D, E, T any](c *Container, f func(p1 A, p2 B, p3 C, p4 D, p5 E) T) error {
+	return add(c, "", LifetimeScoped, from5[A, B, C, D, E, T](f), dependencyOf[A](), dependencyOf[B](), dependencyOf[C](), dependencyOf[D](), dependencyOf[E]())
+}
+
+func TransientFrom5[A, B, C, D, E, T any](c *Container, f func(p1 A, p2 B, p3 C, p4 D, p5 E) T) error {
+	return add(c, "", LifetimeTransient, from5[A, B, C, D, E, T](f), dependencyOf[A](), dependencyOf[B](), dependencyOf[C](), dependencyOf[D](), dependencyOf[E]())
+}
+
+func SingletonFrom6[A, B, C, D, E, F, T any](c *Container, f func(p1 A, p2 B, p3 C, p4 D, p5 E, p6 F) T) error {
+	return add(c, "", LifetimeSingleton, from6[A, B, C, D, E, F, T](f), dependencyOf[A](), dependencyOf[B](), dependencyOf[C](), dependencyOf[D](), dependencyOf[E](), dependencyOf[F]())
+}
+
+func ScopedFrom6[A, B, C, D, E, F, T any](c *Container, f func(p1 A, p2 B, p3 C, p4 D, p5 E, p6 F) T) error {
+	return add(c, "", LifetimeScoped, from6[A, B, C, D, E, F, T](f), dependencyOf[A](), dependencyOf[B](), dependencyOf[C](), dependencyOf[D](), dependencyOf[E](), dependencyOf[F]())
+}
+
+func TransientFrom6[A, B, C, D, E, F, T any](c *Container, f func(p1 A, p2 B, p3 C, p4 D, p5 E, p6 F) T) error {
+	return add(c, "", LifetimeTransient, from6[A, B, C, D, E, F, T](f), dependencyOf[A](), dependencyOf[B](), dependencyOf[C](), dependencyOf[D](), dependencyOf[E](), dependencyOf[F]())
+}
+
+func from1[A, T any](f func(p1 A) T) func(c *Container) (T, error) {
+	return func(c *Container) (T, error) {
+		var result T
+
+		p1, err := getParam[A](c, 0)
+		if err != nil {
+			return result, err
+		}
+
+		return f(p1), nil
+	}
+}
+
+func from2[A, B, T any](f func(p1 A, p2 B) T) func(c *Container) (T, error) {
+	return func(c *Container) (T, error) {
+		var result T
+
+		p1, err := getParam[A](c, 0)
+		if err != nil {
+			return result, err
+		}
+
+		p2, err := getParam[B](c, 1)
+		if err != nil {
+			return result, err
+		}
+
+		return f(p1, p2), nil
+	}
+}
+
+func from3[A, B, C, T any](f func(p1 A, p2 B, p3 C) T) func(c *Container) (T, error) {
+	return func(c *Container) (T, error) {
+		var result T
+
+		p1, err := getParam[A](c, 0)
+		if err != nil {
+			return result, err
+		}
+
+		p2, err := getParam[B](c, 1)
+		if err != nil {
+			return result, err
+		}
+
+		p3, err := getParam[C](c, 2)
+		if err != nil {
+			return result, err
+		}
+
+		return f(p1, p2, p3), nil
+	}
+}
+
+func from4[A, B, C, D, T any](f func(p1 A, p2 B, p3 C, p4 D) T) func(c *Container) (T, error) {
+	return func(c *Container) (T, error) {
+		var result T
+
+		p1, err := getParam[A](c, 0)
+		if err != nil {
+			return result, err
+		}
+
+		p2, err := getParam[B](c, 1)
+		if err != nil {
+			return result, err
+		}
+
+		p3, err := getParam[C](c, 2)
+		if err != nil {
+			return result, err
+		}
+
+		p4, err := getParam[D](c, 3)
+		if err != nil {
+			return result, err
+		}
+
+		return f(p1, p2, p3, p4), nil
+	}
+}
+
+func from5[A, B, C, D, E, T any](f func(p1 A, p2 B, p3 C, p4 D, p5 E) T) func(c *Container) (T, error) {
+	return func(c *Container) (T, error) {
+		var result T
+
+		p1, err := getParam[A](c, 0)
+		if err != nil {
+			return result, err
+		}
+
+		p2, err := getParam[B](c, 1)
+		if err != nil {
+			return result, err
+		}
+
+		p3, err := getParam[C](c, 2)
+		if err != nil {
+			return result, err
+		}
+
+		p4, err := getParam[D](c, 3)
+		if err != nil {
+			return result, err
+		}
+
+		p5, err := getParam[E](c, 4)
+		if err != nil {
+			return result, err
+		}
+
+		return f(p1, p2, p3, p4, p5), nil
+	}
+}
+
+func from6[A, B, C, D, E, F, T any](f func(p1 A, p2 B, p3 C, p4 D, p5 E, p6 F) T) func(c *Container) (T, error) {
+	return func(c *Container) (T, error) {
+		var result T
+
+		p1, err := getParam[A](c, 0)
+		if err != nil {
+			return result, err
+		}
+
+		p2, err := getParam[B](c, 1)
+		if err != nil {
+			return result, err
+		}
+
+		p3, err := getParam[C](c, 2)
+		if err != nil {
+			return result, err
+		}
+
+		p4, err := getParam[D](c, 3)
+		if err != nil {
+			return result, err
+		}
+
+		p5, err := getParam[E](c, 4)
+		if err != nil {
+			return result, err
+		}
+
+		p6, err := getParam[F](c, 5)
+		if err != nil {
+			return result, err
+		}
+
+		return f(p1, p2, p3, p4, p5, p6), nil
+	}
+}
+
+func dependencyOf[P any]() dependency {
+	return dependency{key: getKeyFromT[P]()}
+}
+
+func getParam[P any](c *Container, i int) (P, error) {
+	value, err := Get[P](c)
+	if err != nil {
+		return value, paramError(i, getKeyFromT[P](), err)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,215 @@
+package godi
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var (
+	ErrInvalidInjectTarget = errors.New("inject target must be a pointer to a struct")
+	ErrUnresolvedFields    = errors.New("unresolved fields")
+	ErrUnexportedField     = errors.New("tagged field must be exported")
+)
+
+const injectTag = "godi"
+
+type fieldTag struct {
+	name     string
+	optional bool
+}
+
+// Inject fills the exported fields of the struct pointed by target tagged with `godi:""`
+// from the container. The tag accepts the name of the registration and whether
+// the field is optional, like `godi:"name=readyHandler,optional"`.
+// All the fields that couldn't be resolved are reported in a single error.
+func Inject(c *Container, target any) error {
+	value := reflect.ValueOf(target)
+
+	if value.Kind() != reflect.Pointer || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return ErrInvalidInjectTarget
+	}
+
+	return injectFields(c, value.Elem())
+}
+
+// AutoWire registers a struct, or a pointer to a struct, built by injecting its tagged fields
+func AutoWire[T any](c *Container, lifetime lifetime) error {
+	return autoWire(c, "", lifetime, getKeyFromT[T](), nil)
+}
+
+func AutoWireNamed[T any](c *Container, name string, lifetime lifetime) error {
+	return autoWire(c, name, lifetime, getKeyFromT[T](), nil)
+}
+
+// AutoWireAs registers the struct T for I instead of its own type,
+// so a handler struct can be registered as an http.Handler
+func AutoWireAs[I any, T any](c *Container, lifetime lifetime) error {
+	return autoWire(c, "", lifetime, getKeyFromT[T](), getKeyFromT[I]())
+}
+
+func AutoWireNamedAs[I any, T any](c *Container, name string, lifetime lifetime) error {
+	return autoWire(c, name, lifetime, getKeyFromT[T](), getKeyFromT[I]())
+}
+
+func autoWire(c *Container, name string, lifetime lifetime, key reflect.Type, as reflect.Type) error {
+	structType := key
+	if structType.Kind() == reflect.Pointer {
+		structType = structType.Elem()
+	}
+
+	if structType.Kind() != reflect.Struct {
+		return ErrInvalidInjectTarget
+	}
+
+	deps, err := fieldDependencies(structType)
+	if err != nil {
+		return err
+	}
+
+	defKey := key
+
+	if as != nil {
+		if !key.AssignableTo(as) {
+			return fmt.Errorf("%w: %v is not assignable to %v", ErrInvalidInjectTarget, key, as)
+		}
+
+		defKey = as
+	}
+
+	return addDefinition(c, defKey, name, &definition{
+		lifetime: lifetime,
+		deps:     deps,
+		factory: func(c *Container) (any, error) {
+			value := reflect.New(structType)
+
+			err := injectFields(c, value.Elem())
+			if err != nil {
+				return nil, err
+			}
+
+			if key.Kind() == reflect.Pointer {
+				return value.Interface(), nil
+			}
+
+			return value.Elem().Interface(), nil
+		},
+	})
+}
+
+// Returns the dependencies declared by the tagged fields of the struct
+func fieldDependencies(structType reflect.Type) ([]dependency, error) {
+	var deps []dependency
+
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+
+		rawTag, tagged := field.Tag.Lookup(injectTag)
+		if !tagged {
+			continue
+		}
+
+		if !field.IsExported() {
+			return nil, fmt.Errorf("%w in %v: field %v: %w", ErrUnresolvedFields, structType, field.Name, ErrUnexportedField)
+		}
+
+		if field.Type == containerType {
+			continue
+		}
+
+		tag, err := parseFieldTag(rawTag)
+		if err != nil {
+			return nil, fmt.Errorf("%w in %v: field %v: %w", ErrUnresolvedFields, structType, field.Name, err)
+		}
+
+		deps = append(deps, dependency{key: field.Type, name: tag.name, optional: tag.optional})
+	}
+
+	return deps, nil
+}
+
+func injectFields(c *Container, value reflect.Value) error {
+	structType := value.Type()
+
+	var errs []error
+
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+
+		rawTag, tagged := field.Tag.Lookup(injectTag)
+		if !tagged {
+			continue
+		}
+
+		// Unexported fields of other packages can't be set without bypassing their encapsulation
+		if !field.IsExported() {
+			errs = append(errs, fmt.Errorf("field %v: %w", field.Name, ErrUnexportedField))
+			continue
+		}
+
+		tag, err := parseFieldTag(rawTag)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("field %v: %w", field.Name, err))
+			continue
+		}
+
+		err = injectField(c, value.Field(i), tag)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("field %v (%v): %w", field.Name, field.Type, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%w in %v: %w", ErrUnresolvedFields, structType, errors.Join(errs...))
+	}
+
+	return nil
+}
+
+func injectField(c *Container, field reflect.Value, tag fieldTag) error {
+	if field.Type() == containerType {
+		field.Set(reflect.ValueOf(c))
+
+		return nil
+	}
+
+	// Optional fields are left empty only if there is no registration,
+	// a registration that fails to build is still reported
+	if tag.optional {
+		if _, err := lookup(c, field.Type(), tag.name); err != nil {
+			return nil
+		}
+	}
+
+	value, err := resolve(c, field.Type(), tag.name)
+	if err != nil {
+		return err
+	}
+
+	if value != nil {
+		field.Set(reflect.ValueOf(value))
+	}
+
+	return nil
+}
+
+func parseFieldTag(rawTag string) (fieldTag, error) {
+	var tag fieldTag
+
+	for _, option := range strings.Split(rawTag, ",") {
+		option = strings.TrimSpace(option)
+
+		switch {
+		case option == "":
+		case option == "optional":
+			tag.optional = true
+		case strings.HasPrefix(option, "name="):
+			tag.name = strings.TrimPrefix(option, "name=")
+		default:
+			return tag, fmt.Errorf("unknown tag option %q", option)
+		}
+	}
+
+	return tag, nil
+}
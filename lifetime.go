@@ -1,8 +1,10 @@
 package godi
 
 import (
+	"errors"
 	"reflect"
 	"sync"
+	"sync/atomic"
 )
 
 type lifetime string
@@ -13,13 +15,197 @@ const (
 	LifetimeTransient lifetime = "Transient"
 )
 
+var ErrInvalidLifetime = errors.New("lifetime must be Singleton, Scoped or Transient")
+
+// The lifetime type is not exported, but untyped string constants can still be passed for it
+func (l lifetime) valid() bool {
+	return l == LifetimeSingleton || l == LifetimeScoped || l == LifetimeTransient
+}
+
+// The cache is read mostly, entries are only added the first time a type and name is resolved.
+// Lookups take the read lock, and creating an entry the write lock.
+// Its maps are created when first used, so an empty cache is ready to use.
 type lifetimeCache struct {
-	entries map[reflect.Type]map[string]*cacheEntry
-	mx      sync.Mutex
+	entries map[cacheKey]*cacheEntry
+	mx      sync.RWMutex
+	// Built entries in creation order, so they can be disposed in reverse
+	built  []*cacheEntry
+	closed atomic.Bool
+	// Result of disposing the cache, so it is only done once
+	disposeOnce sync.Once
+	disposeErr  error
+	// Entries of the frozen scoped definitions, created at once on the first lookup
+	frozen atomic.Pointer[[]cacheEntry]
 }
 
+type cacheKey struct {
+	key  reflect.Type
+	name string
+}
+
+// The lock of the entry is held while building its instance, so it is built exactly once.
+// The built value is published atomically, so it can be read without holding the lock
+// and a nil value means the entry is not initialized.
 type cacheEntry struct {
-	mx          sync.Mutex
-	initialized bool
-	instance    any
+	mx    sync.Mutex
+	value atomic.Pointer[cachedValue]
+	key   reflect.Type
+	name  string
+	// Registration building the instance while holding the lock
+	building atomic.Pointer[resolution]
+}
+
+type cachedValue struct {
+	instance any
+	// Instance before being decorated
+	raw any
+}
+
+// Returns the entry for the type and name creating it if it doesn't exist
+func (cache *lifetimeCache) entry(key reflect.Type, name string) *cacheEntry {
+	k := cacheKey{key: key, name: name}
+
+	cache.mx.RLock()
+	entry, found := cache.entries[k]
+	cache.mx.RUnlock()
+
+	if found {
+		return entry
+	}
+
+	cache.mx.Lock()
+	defer cache.mx.Unlock()
+
+	// Another goroutine might have created it while waiting for the lock
+	entry, found = cache.entries[k]
+
+	if !found {
+		if cache.entries == nil {
+			cache.entries = make(map[cacheKey]*cacheEntry)
+		}
+
+		entry = &cacheEntry{key: key, name: name}
+		cache.entries[k] = entry
+	}
+
+	return entry
+}
+
+// Returns the entry of a frozen scoped definition without taking any lock,
+// the entries of all of them are created the first time one is needed on the scope
+func (cache *lifetimeCache) frozenEntry(frozen *frozenDefinitions, index int) *cacheEntry {
+	entries := cache.frozen.Load()
+
+	if entries == nil {
+		created := make([]cacheEntry, len(frozen.scoped))
+
+		cache.mx.RLock()
+
+		for i, k := range frozen.scoped {
+			created[i].key = k.key
+			created[i].name = k.name
+
+			// Instances built before freezing, on the root container, are kept
+			if entry, found := cache.entries[k]; found {
+				created[i].value.Store(entry.value.Load())
+			}
+		}
+
+		cache.mx.RUnlock()
+
+		// Another goroutine might have created them first, in which case those are used
+		cache.frozen.CompareAndSwap(nil, &created)
+		entries = cache.frozen.Load()
+	}
+
+	return &(*entries)[index]
+}
+
+// Takes the lock of the entry to build it for the resolution. If the entry is being built by another goroutine
+// waiting, directly or through other entries, for an entry being built by the resolution, waiting would never end
+// and ErrCircularDependency is returned instead. The resolution is marked as waiting before checking,
+// so when two goroutines close a cycle at the same time at least one of them sees the other one waiting.
+func (entry *cacheEntry) lockFor(r *resolution) error {
+	if !entry.mx.TryLock() {
+		r.wait(entry)
+
+		if entry.waitsFor(r) {
+			r.wait(nil)
+
+			return ErrCircularDependency
+		}
+
+		entry.mx.Lock()
+		r.wait(nil)
+	}
+
+	entry.building.Store(r)
+
+	return nil
+}
+
+func (entry *cacheEntry) unlock() {
+	entry.building.Store(nil)
+	entry.mx.Unlock()
+}
+
+// Follows the registrations building the entries and the entries they wait for,
+// reaching a registration of the resolution means it would wait for itself
+func (entry *cacheEntry) waitsFor(r *resolution) bool {
+	visited := make(map[*cacheEntry]bool)
+
+	for e := entry; e != nil && !visited[e]; {
+		visited[e] = true
+
+		owner := e.building.Load()
+		if owner == nil {
+			return false
+		}
+
+		if r.includes(owner) {
+			return true
+		}
+
+		e = owner.waiting.Load()
+	}
+
+	return false
+}
+
+// Marks the entry as not initialized so it is built again on the next request,
+// waiting for the instance to be built if it is being built
+func (entry *cacheEntry) reset() {
+	entry.mx.Lock()
+	entry.value.Store(nil)
+	entry.mx.Unlock()
+}
+
+// Records the entry as built for disposal, returns false if the cache was closed meanwhile.
+// Closing the cache takes the lock to collect the built entries, so an entry is either
+// collected or reported here.
+func (cache *lifetimeCache) track(entry *cacheEntry) bool {
+	cache.mx.Lock()
+	defer cache.mx.Unlock()
+
+	if cache.closed.Load() {
+		return false
+	}
+
+	cache.built = append(cache.built, entry)
+
+	return true
+}
+
+// Returns the instance and whether the entry is initialized
+func (entry *cacheEntry) load() (any, bool) {
+	value := entry.value.Load()
+	if value == nil {
+		return nil, false
+	}
+
+	return value.instance, true
+}
+
+func (entry *cacheEntry) store(instance any, raw any) {
+	entry.value.Store(&cachedValue{instance: instance, raw: raw})
 }
@@ -0,0 +1,97 @@
+package godi
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// Definitions guarded by a lock, registrations can happen at any time
+// while other goroutines are resolving instances.
+// Registered definitions are never modified,
+// so a definition can be used without holding the lock once it has been looked up.
+// Its map is created when first used, so an empty registry is ready to use.
+type registry struct {
+	mx   sync.RWMutex
+	defs map[reflect.Type]map[string]*definition
+	// Count of scoped definitions inheriting a global definition
+	overrides atomic.Int32
+	// Decorators by type, applied when building the instances of any definition of the type,
+	// including the ones registered after the decorator
+	decorators map[reflect.Type][]decorator
+	// Count of decorators registered, to skip looking them up on scopes without decorators
+	decorated atomic.Int32
+}
+
+func (r *registry) get(key reflect.Type, name string) (*definition, bool) {
+	r.mx.RLock()
+	d, found := r.defs[key][name]
+	r.mx.RUnlock()
+
+	return d, found
+}
+
+func (r *registry) names(key reflect.Type) []string {
+	r.mx.RLock()
+	defer r.mx.RUnlock()
+
+	names := make([]string, 0, len(r.defs[key]))
+
+	// Declared definitions are only available once registered on a scope
+	for name, d := range r.defs[key] {
+		if !d.declared {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// Needs to be called holding the write lock
+func (r *registry) set(key reflect.Type, name string, d *definition) {
+	if r.defs == nil {
+		r.defs = make(map[reflect.Type]map[string]*definition)
+	}
+
+	typeDef, foundTypeDef := r.defs[key]
+
+	if !foundTypeDef {
+		typeDef = make(map[string]*definition)
+		r.defs[key] = typeDef
+	}
+
+	typeDef[name] = d
+}
+
+func (r *registry) decoratorsFor(key reflect.Type) []decorator {
+	if r.decorated.Load() == 0 {
+		return nil
+	}
+
+	r.mx.RLock()
+	decorators := r.decorators[key]
+	r.mx.RUnlock()
+
+	return decorators
+}
+
+// Needs to be called holding the write lock
+func (r *registry) addDecorator(key reflect.Type, dec decorator) {
+	if r.decorators == nil {
+		r.decorators = make(map[reflect.Type][]decorator)
+	}
+
+	// The slice is replaced with a copy, as it might being used by a concurrent Get.
+	// Decorators are kept sorted by order, after the ones registered before with the same order.
+	current := r.decorators[key]
+	position := sort.Search(len(current), func(i int) bool {
+		return current[i].order > dec.order
+	})
+
+	decorators := make([]decorator, 0, len(current)+1)
+	decorators = append(decorators, current[:position]...)
+	decorators = append(decorators, dec)
+	r.decorators[key] = append(decorators, current[position:]...)
+	r.decorated.Add(1)
+}
@@ -0,0 +1,158 @@
+package godi
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync/atomic"
+)
+
+var (
+	ErrCaptiveDependency  = errors.New("captive dependency")
+	ErrCircularDependency = errors.New("circular dependency")
+)
+
+// CaptiveDependencyMode defines what happens when a singleton resolves a scoped or transient
+// dependency, which would be captured by the singleton for the duration of the process
+type CaptiveDependencyMode int
+
+const (
+	// Get returns ErrCaptiveDependency, this is the default
+	CaptiveDependencyError CaptiveDependencyMode = iota
+	// Get panics with ErrCaptiveDependency
+	CaptiveDependencyPanic
+	// Lenient mode, dependencies are not checked
+	CaptiveDependencyAllow
+)
+
+// Registration being built, linked to the one that requested it
+type resolution struct {
+	key      reflect.Type
+	name     string
+	lifetime lifetime
+	// Registered on a scope
+	local  bool
+	parent *resolution
+	// Set once built, factories keeping the container they received can keep resolving through it
+	done atomic.Bool
+	// Cache entry the resolution waits for while building, to detect cycles across goroutines
+	waiting atomic.Pointer[cacheEntry]
+}
+
+// SetCaptiveDependencyMode applies to the container and all its scopes
+func (c *Container) SetCaptiveDependencyMode(mode CaptiveDependencyMode) {
+	c.shared.captiveMode.Store(int32(mode))
+}
+
+// Returns a copy of the container tracking the registration being built, which is passed to factories
+// and decorators. Go has no goroutine local state, so the chain is carried by the container they receive.
+// The copy shares the registrations, caches and scope of the container, only its pointer is different.
+// The resolution is finished once built, so a factory can keep the container to resolve later.
+func (c *Container) resolvingFor(key reflect.Type, name string, d *definition) *Container {
+	resolvingCont := *c
+	resolvingCont.resolving = &resolution{
+		key:      key,
+		name:     name,
+		lifetime: d.lifetime,
+		local:    d.owner != nil,
+		parent:   c.resolving,
+	}
+
+	return &resolvingCont
+}
+
+func (r *resolution) finish() {
+	r.done.Store(true)
+}
+
+// Returns the closest registration still being built, skipping the ones already built
+func (r *resolution) pending() *resolution {
+	for r != nil && r.done.Load() {
+		r = r.parent
+	}
+
+	return r
+}
+
+// Marks the registrations being built as waiting for the entry, nil when done waiting
+func (r *resolution) wait(entry *cacheEntry) {
+	for r = r.pending(); r != nil; r = r.parent.pending() {
+		r.waiting.Store(entry)
+	}
+}
+
+func (r *resolution) includes(other *resolution) bool {
+	for ; r != nil; r = r.parent {
+		if r == other {
+			return true
+		}
+	}
+
+	return false
+}
+
+// A singleton being built captures anything it resolves, even through transient registrations,
+// so scoped and transient registrations can't be resolved while building a singleton.
+// Singletons registered on a scope live as long as the scope, like scoped registrations,
+// so they can depend on any registration but singletons of the container can't depend on them.
+func checkCaptiveDependency(c *Container, key reflect.Type, name string, d *definition) error {
+	mode := CaptiveDependencyMode(c.shared.captiveMode.Load())
+
+	if mode == CaptiveDependencyAllow || (d.lifetime == LifetimeSingleton && d.owner == nil) {
+		return nil
+	}
+
+	for r := c.resolving.pending(); r != nil; r = r.parent.pending() {
+		if r.lifetime != LifetimeSingleton || r.local {
+			continue
+		}
+
+		err := resolutionError(c, key, name, d, fmt.Errorf("%w: %v %v depends on %v %v",
+			ErrCaptiveDependency, r.lifetime, describe(r.key, r.name), describeLifetime(d), describe(key, name)))
+
+		if mode == CaptiveDependencyPanic {
+			panic(err)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func describeLifetime(d *definition) string {
+	if d.owner != nil && d.lifetime == LifetimeSingleton {
+		return "scope " + string(d.lifetime)
+	}
+
+	return string(d.lifetime)
+}
+
+// Resolving a registration that is already being built on the same resolution would never end,
+// for singletons and scoped it would deadlock waiting for the lock of its own cache entry.
+// Cycles across goroutines are detected when waiting for the lock, see cacheEntry.lockFor
+func checkCircularDependency(c *Container, key reflect.Type, name string) error {
+	for r := c.resolving.pending(); r != nil; r = r.parent.pending() {
+		if r.key == key && r.name == name {
+			return fmt.Errorf("%w: %v", ErrCircularDependency, c.resolving.path(key, name))
+		}
+	}
+
+	return nil
+}
+
+// Describes the chain of registrations being built, ending with the one requested
+func (r *resolution) path(key reflect.Type, name string) string {
+	steps := []string{describe(key, name)}
+
+	for r = r.pending(); r != nil; r = r.parent.pending() {
+		steps = append(steps, describe(r.key, r.name))
+	}
+
+	for i, j := 0, len(steps)-1; i < j; i, j = i+1, j-1 {
+		steps[i], steps[j] = steps[j], steps[i]
+	}
+
+	return strings.Join(steps, " -> ")
+}
@@ -0,0 +1,214 @@
+package godi
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const maxSuggestions = 5
+
+// ResolutionError describes a registration that couldn't be resolved,
+// it unwraps to the cause, like ErrFactoryNotRegistered or ErrCircularDependency
+type ResolutionError struct {
+	Type reflect.Type
+	Name string
+	// Lifetime of the registration, empty when it is not registered
+	Lifetime lifetime
+	// 0 for the container, 1 for its scopes, 2 for their nested scopes...
+	ScopeDepth int
+	// Registrations being built when the registration was requested, the first one requested first
+	Chain []string
+	// Registrations with a similar type or name, when the registration is not registered
+	Suggestions []string
+	Err         error
+}
+
+func (e *ResolutionError) Error() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "resolving %v", describe(e.Type, e.Name))
+
+	if e.ScopeDepth > 0 {
+		fmt.Fprintf(&sb, " on scope depth %v", e.ScopeDepth)
+	}
+
+	if len(e.Chain) > 0 {
+		fmt.Fprintf(&sb, " required by %v", strings.Join(e.Chain, " -> "))
+	}
+
+	fmt.Fprintf(&sb, ": %v", e.Err)
+
+	if len(e.Suggestions) > 0 {
+		fmt.Fprintf(&sb, ", did you mean %v?", strings.Join(e.Suggestions, ", "))
+	}
+
+	return sb.String()
+}
+
+func (e *ResolutionError) Unwrap() error {
+	return e.Err
+}
+
+// Wraps the error with the details of the registration being resolved, d is nil if it is not registered
+func resolutionError(c *Container, key reflect.Type, name string, d *definition, err error) *ResolutionError {
+	resolutionErr := &ResolutionError{
+		Type:       key,
+		Name:       name,
+		ScopeDepth: c.depth(),
+		Chain:      c.resolving.chain(),
+		Err:        err,
+	}
+
+	if d != nil {
+		resolutionErr.Lifetime = d.lifetime
+	}
+
+	if err == ErrFactoryNotRegistered {
+		resolutionErr.Suggestions = suggestions(c, key, name)
+	}
+
+	return resolutionErr
+}
+
+// Returns the depth of the scope, 0 for the container
+func (c *Container) depth() int {
+	depth := 0
+
+	for scope := c; scope != nil && scope.scopedDef != nil; scope = scope.parent {
+		depth++
+	}
+
+	return depth
+}
+
+// Returns the registrations being built, the first one requested first
+func (r *resolution) chain() []string {
+	var chain []string
+
+	for r = r.pending(); r != nil; r = r.parent.pending() {
+		chain = append(chain, describe(r.key, r.name))
+	}
+
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+
+	return chain
+}
+
+// Finds registrations visible from the container that were likely meant instead of the requested one:
+// other names for the type and, for the same name, the pointer or element of the type,
+// implementations of the interface and types with a similar spelling
+func suggestions(c *Container, key reflect.Type, name string) []string {
+	var result []string
+
+	for _, k := range registeredKeys(c) {
+		similar := false
+
+		switch {
+		case k.key == key:
+			similar = true
+		case k.name != name:
+		case k.key == reflect.PointerTo(key) || (key.Kind() == reflect.Pointer && k.key == key.Elem()):
+			similar = true
+		case key.Kind() == reflect.Interface && k.key.Implements(key):
+			similar = true
+		default:
+			similar = isSimilar(k.key.String(), key.String())
+		}
+
+		if similar {
+			result = append(result, describe(k.key, k.name))
+		}
+	}
+
+	sort.Strings(result)
+
+	// The same registration can be overridden on several scopes
+	unique := result[:0]
+
+	for i, suggestion := range result {
+		if i == 0 || suggestion != result[i-1] {
+			unique = append(unique, suggestion)
+		}
+	}
+
+	result = unique
+
+	if len(result) > maxSuggestions {
+		result = result[:maxSuggestions]
+	}
+
+	return result
+}
+
+// Returns the types and names registered on the container and the scope chain
+func registeredKeys(c *Container) []cacheKey {
+	registries := []*registry{c.globalDef}
+
+	for scope := c; scope != nil && scope.scopedDef != nil; scope = scope.parent {
+		registries = append(registries, scope.scopedDef)
+	}
+
+	var keys []cacheKey
+
+	for _, r := range registries {
+		r.mx.RLock()
+
+		for key, typeDef := range r.defs {
+			for name, d := range typeDef {
+				if !d.declared {
+					keys = append(keys, cacheKey{key: key, name: name})
+				}
+			}
+		}
+
+		r.mx.RUnlock()
+	}
+
+	return keys
+}
+
+// Whether a is a likely misspelling of b, ignoring case and allowing a couple of edits
+func isSimilar(a, b string) bool {
+	a, b = strings.ToLower(a), strings.ToLower(b)
+
+	return a == b || editDistance(a, b) <= 2
+}
+
+// Levenshtein distance between both strings
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			current[j] = previous[j-1] + cost
+
+			if previous[j]+1 < current[j] {
+				current[j] = previous[j] + 1
+			}
+
+			if current[j-1]+1 < current[j] {
+				current[j] = current[j-1] + 1
+			}
+		}
+
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
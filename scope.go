@@ -0,0 +1,138 @@
+package godi
+
+import (
+	"errors"
+	"reflect"
+)
+
+var ErrNoParentScope = errors.New("scope has no parent scope to inherit from")
+
+// Inherit declares that the scope shares the scoped instance of T with its parent scope,
+// instead of building its own instance, like a transaction shared by the operations of a request.
+// Disposing the instance is left to the parent scope.
+func Inherit[T any](c *Container) error {
+	return InheritNamed[T](c, "")
+}
+
+func InheritNamed[T any](c *Container, name string) error {
+	if c.parent == nil {
+		return ErrNoParentScope
+	}
+
+	return addDefinition(c, getKeyFromT[T](), name, &definition{
+		lifetime:  LifetimeScoped,
+		inherited: true,
+	})
+}
+
+// Resolves the instance from the parent scope, keeping track of the registrations being built
+func resolveInherited(c *Container, key reflect.Type, name string, undecorated bool) (any, any, error) {
+	parent := *c.parent
+	parent.resolving = c.resolving
+
+	return resolveValues(&parent, key, name, undecorated)
+}
+
+// Singletons registered on a scope are built on the scope that registered them and cached on it,
+// so they are shared with its nested scopes and disposed when the scope is closed
+func resolveScopeSingleton(c *Container, key reflect.Type, name string, d *definition) (any, any, error) {
+	if d.owner.scopedCache.closed.Load() {
+		return nil, nil, resolutionError(c, key, name, d, ErrScopeClosed)
+	}
+
+	owner := *d.owner
+	owner.resolving = c.resolving
+
+	return getFromCacheOrBuild(&owner, owner.scopedCache, owner.scopedCache.entry(key, name), key, name, d)
+}
+
+// Returns the decorators registered for the type on the scope and its parents by order, parents first.
+// It stops at the scope until, which applies its own decorators when building its instances.
+func scopeDecorators(c *Container, until *Container, key reflect.Type) []decorator {
+	var decorators []decorator
+
+	for scope := c; scope != nil && scope.scopedDef != nil; scope = scope.parent {
+		if until != nil && scope.scopedDef == until.scopedDef {
+			break
+		}
+
+		decorators = mergeDecorators(scope.scopedDef.decoratorsFor(key), decorators)
+	}
+
+	return decorators
+}
+
+// Instances shared with other scopes are decorated again when the scope has decorators for them,
+// caching the decorated instance on the scope. The instance before being decorated is wrapped by the decorators
+// of the container and the scopes merged by order, so the ones of the scope don't always wrap the others.
+// The scope doesn't dispose it, the decorated instance is still owned by whoever built it.
+func decorateShared(
+	c *Container,
+	until *Container,
+	key reflect.Type,
+	name string,
+	d *definition,
+	value any,
+	raw any,
+	err error) (any, any, error) {
+	if err != nil || c.scopedDef == nil {
+		return value, raw, err
+	}
+
+	if len(applicable(scopeDecorators(c, until, key), name, d)) == 0 {
+		return value, raw, nil
+	}
+
+	entry := c.scopedCache.entry(key, name)
+	if instance, initialized := entry.load(); initialized {
+		return instance, raw, nil
+	}
+
+	entry.mx.Lock()
+	defer entry.mx.Unlock()
+
+	if instance, initialized := entry.load(); initialized {
+		return instance, raw, nil
+	}
+
+	decorators := applicable(mergeDecorators(globalDecorators(c, key, d), scopeDecorators(c, nil, key)), name, d)
+
+	// The decorated instance lives as long as the scope, like a scoped registration
+	resolvingCont := c.resolvingFor(key, name, &definition{lifetime: LifetimeScoped})
+	defer resolvingCont.resolving.finish()
+
+	value, err = decorate(resolvingCont, key, name, raw, decorators)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The entry of the decorated instance is only used for the instance
+	entry.store(value, nil)
+
+	return value, raw, nil
+}
+
+// A scope being built holds the registrations being built,
+// which should not be carried to scopes created from it
+func (c *Container) withoutResolving() *Container {
+	if c.resolving == nil {
+		return c
+	}
+
+	scope := *c
+	scope.resolving = nil
+
+	return &scope
+}
+
+// Whether the scope or any of its parents inherit a global definition,
+// in which case the scopes need to be looked up before the global definitions
+func (c *Container) overridesGlobal() bool {
+	for scope := c; scope != nil && scope.scopedDef != nil; scope = scope.parent {
+		if scope.scopedDef.overrides.Load() > 0 {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,125 @@
+package test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mingue/godi"
+)
+
+func TestCaptiveScopedDependencyOnSingleton(t *testing.T) {
+	var cont = godi.New()
+	godi.Scoped(cont, func(c *godi.Container) *SomeStruct {
+		return &SomeStruct{}
+	})
+	godi.SingletonE(cont, func(c *godi.Container) (Repository, error) {
+		some, err := godi.Get[*SomeStruct](c)
+		if err != nil {
+			return nil, err
+		}
+
+		return &RepositoryImpl{name: some.data}, nil
+	})
+
+	_, err := godi.Get[Repository](cont.NewScope())
+	if !errors.Is(err, godi.ErrCaptiveDependency) {
+		t.Fatalf("Expecting captive dependency, got: %v", err)
+	}
+
+	if !strings.Contains(err.Error(), "test.Repository") || !strings.Contains(err.Error(), "*test.SomeStruct") {
+		t.Fatalf("Error should contain both types: %v", err.Error())
+	}
+}
+
+func TestCaptiveTransientDependencyOnSingleton(t *testing.T) {
+	var cont = godi.New()
+	godi.Transient(cont, func(c *godi.Container) *SomeStruct {
+		return &SomeStruct{}
+	})
+	godi.Provide(cont, godi.LifetimeSingleton, NewService)
+	godi.ProvideAs[Repository](cont, godi.LifetimeSingleton, NewRepositoryImpl)
+
+	_, err := godi.Get[*Service](cont)
+	if !errors.Is(err, godi.ErrCaptiveDependency) {
+		t.Fatalf("Expecting captive dependency, got: %v", err)
+	}
+}
+
+func TestCaptiveDependencyThroughTransient(t *testing.T) {
+	var cont = godi.New()
+	godi.Scoped(cont, func(c *godi.Container) *SomeStruct {
+		return &SomeStruct{}
+	})
+	godi.TransientFrom1(cont, func(s *SomeStruct) Repository {
+		return &RepositoryImpl{}
+	})
+	godi.SingletonFrom1(cont, func(r Repository) *Service {
+		return &Service{repo: r}
+	})
+
+	_, err := godi.Get[*Service](cont)
+	if !errors.Is(err, godi.ErrCaptiveDependency) {
+		t.Fatalf("Expecting captive dependency, got: %v", err)
+	}
+}
+
+func TestShorterLifetimesCanDependOnLongerLifetimes(t *testing.T) {
+	var cont = godi.New()
+	godi.ProvideAs[Repository](cont, godi.LifetimeSingleton, NewRepositoryImpl)
+	godi.ScopedFrom1(cont, func(r Repository) *SomeStruct {
+		return &SomeStruct{data: r.Name()}
+	})
+	godi.Provide(cont, godi.LifetimeTransient, NewService)
+
+	_, err := godi.Get[*Service](cont.NewScope())
+	if err != nil {
+		t.Fatalf("Failed to get instance: %v", err.Error())
+	}
+}
+
+func TestCaptiveDependencyPanicMode(t *testing.T) {
+	var cont = godi.New()
+	cont.SetCaptiveDependencyMode(godi.CaptiveDependencyPanic)
+	godi.Scoped(cont, func(c *godi.Container) *SomeStruct {
+		return &SomeStruct{}
+	})
+	godi.SingletonE(cont, func(c *godi.Container) (Repository, error) {
+		some, err := godi.Get[*SomeStruct](c)
+		if err != nil {
+			return nil, err
+		}
+
+		return &RepositoryImpl{name: some.data}, nil
+	})
+
+	defer func() {
+		err, _ := recover().(error)
+		if !errors.Is(err, godi.ErrCaptiveDependency) {
+			t.Fatalf("Expecting captive dependency panic, got: %v", err)
+		}
+	}()
+
+	godi.Get[Repository](cont)
+}
+
+func TestCaptiveDependencyAllowMode(t *testing.T) {
+	var cont = godi.New()
+	cont.SetCaptiveDependencyMode(godi.CaptiveDependencyAllow)
+	godi.Scoped(cont, func(c *godi.Container) *SomeStruct {
+		return &SomeStruct{}
+	})
+	godi.SingletonE(cont, func(c *godi.Container) (Repository, error) {
+		some, err := godi.Get[*SomeStruct](c)
+		if err != nil {
+			return nil, err
+		}
+
+		return &RepositoryImpl{name: some.data}, nil
+	})
+
+	_, err := godi.Get[Repository](cont.NewScope())
+	if err != nil {
+		t.Fatalf("Lenient mode should allow captive dependencies: %v", err.Error())
+	}
+}
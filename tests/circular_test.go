@@ -0,0 +1,166 @@
+package test
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mingue/godi"
+)
+
+type (
+	CircularA struct{ b *CircularB }
+	CircularB struct{ a *CircularA }
+)
+
+func TestCircularDependencyForEveryLifetime(t *testing.T) {
+	lifetimes := map[string]func(c *godi.Container, ctor any) error{
+		"Singleton": func(c *godi.Container, ctor any) error { return godi.Provide(c, godi.LifetimeSingleton, ctor) },
+		"Scoped":    func(c *godi.Container, ctor any) error { return godi.Provide(c, godi.LifetimeScoped, ctor) },
+		"Transient": func(c *godi.Container, ctor any) error { return godi.Provide(c, godi.LifetimeTransient, ctor) },
+	}
+
+	for name, lifetime := range lifetimes {
+		var cont = godi.New()
+		lifetime(cont, func(b *CircularB) *CircularA {
+			return &CircularA{b: b}
+		})
+		lifetime(cont, func(a *CircularA) *CircularB {
+			return &CircularB{a: a}
+		})
+
+		done := make(chan error, 1)
+		go func() {
+			_, err := godi.Get[*CircularA](cont)
+			done <- err
+		}()
+
+		select {
+		case err := <-done:
+			if !errors.Is(err, godi.ErrCircularDependency) {
+				t.Fatalf("%v: expecting circular dependency, got: %v", name, err)
+			}
+
+			if !strings.Contains(err.Error(), "*test.CircularA -> *test.CircularB -> *test.CircularA") {
+				t.Fatalf("%v: error should contain the resolution path: %v", name, err.Error())
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%v: resolution should not deadlock", name)
+		}
+	}
+}
+
+func TestCircularDependencyPathIncludesNames(t *testing.T) {
+	var cont = godi.New()
+	godi.TransientNamedE(cont, "self", func(c *godi.Container) (*SomeStruct, error) {
+		_, err := godi.GetNamed[*SomeStruct](c, "self")
+		return &SomeStruct{}, err
+	})
+
+	_, err := godi.GetNamed[*SomeStruct](cont, "self")
+	if !errors.Is(err, godi.ErrCircularDependency) {
+		t.Fatalf("Expecting circular dependency, got: %v", err)
+	}
+
+	if !strings.Contains(err.Error(), `*test.SomeStruct named "self" -> *test.SomeStruct named "self"`) {
+		t.Fatalf("Error should contain the names on the resolution path: %v", err.Error())
+	}
+}
+
+func TestSameTypeWithDifferentNamesIsNotCircular(t *testing.T) {
+	var cont = godi.New()
+	godi.Transient(cont, func(c *godi.Container) *SomeStruct {
+		return &SomeStruct{data: "inner"}
+	})
+	godi.TransientNamedE(cont, "outer", func(c *godi.Container) (*SomeStruct, error) {
+		return godi.Get[*SomeStruct](c)
+	})
+
+	x, err := godi.GetNamed[*SomeStruct](cont, "outer")
+	if err != nil || x.data != "inner" {
+		t.Fatalf("Failed to get instance: %v", err)
+	}
+}
+
+func TestCircularDependencyAcrossGoroutines(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		var cont = godi.New()
+		var onceA, onceB sync.Once
+		startedA, startedB := make(chan struct{}), make(chan struct{})
+
+		// Each factory waits for the other one to start, so each goroutine holds the entry the other one needs
+		godi.SingletonE(cont, func(c *godi.Container) (*CircularA, error) {
+			onceA.Do(func() { close(startedA) })
+			<-startedB
+			b, err := godi.Get[*CircularB](c)
+			return &CircularA{b: b}, err
+		})
+		godi.SingletonE(cont, func(c *godi.Container) (*CircularB, error) {
+			onceB.Do(func() { close(startedB) })
+			<-startedA
+			a, err := godi.Get[*CircularA](c)
+			return &CircularB{a: a}, err
+		})
+
+		done := make(chan error, 2)
+		go func() {
+			_, err := godi.Get[*CircularA](cont)
+			done <- err
+		}()
+		go func() {
+			_, err := godi.Get[*CircularB](cont)
+			done <- err
+		}()
+
+		for j := 0; j < 2; j++ {
+			select {
+			case err := <-done:
+				if !errors.Is(err, godi.ErrCircularDependency) {
+					t.Fatalf("Expecting circular dependency, got: %v", err)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("Resolving a cycle from two goroutines should not deadlock")
+			}
+		}
+	}
+}
+
+type Holder struct {
+	c *godi.Container
+}
+
+func NewHolder(c *godi.Container) *Holder {
+	return &Holder{c: c}
+}
+
+func TestResolvingThroughContainerKeptByFactory(t *testing.T) {
+	for _, lifetime := range []string{"Singleton", "Transient"} {
+		var cont = godi.New()
+		godi.Scoped(cont, func(c *godi.Container) *SomeStruct {
+			return &SomeStruct{}
+		})
+
+		if lifetime == "Singleton" {
+			godi.Provide(cont, godi.LifetimeSingleton, NewHolder)
+		} else {
+			godi.Provide(cont, godi.LifetimeTransient, NewHolder)
+		}
+
+		h, err := godi.Get[*Holder](cont)
+		if err != nil {
+			t.Fatalf("Failed to get instance: %v", err.Error())
+		}
+
+		// The registration was built, resolving through the container it kept is not a cycle
+		if _, err := godi.Get[*Holder](h.c); err != nil {
+			t.Fatalf("%v should resolve through the container it kept, got: %v", lifetime, err)
+		}
+
+		// Nor a captive dependency, as the singleton is not being built anymore
+		if _, err := godi.Get[*SomeStruct](h.c); err != nil {
+			t.Fatalf("%v should resolve scoped through the container it kept, got: %v", lifetime, err)
+		}
+	}
+}
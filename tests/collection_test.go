@@ -0,0 +1,154 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mingue/godi"
+)
+
+func TestGetAllReturnsEveryRegistrationInOrder(t *testing.T) {
+	var cont = godi.New()
+	godi.TransientNamed(cont, "b", func(c *godi.Container) *SomeStruct {
+		return &SomeStruct{data: "b"}
+	})
+	godi.Singleton(cont, func(c *godi.Container) *SomeStruct {
+		return &SomeStruct{data: ""}
+	})
+	godi.ScopedNamed(cont, "a", func(c *godi.Container) *SomeStruct {
+		return &SomeStruct{data: "a"}
+	})
+
+	all, err := godi.GetAll[*SomeStruct](cont)
+	if err != nil {
+		t.Fatalf("Failed to get instances: %v", err.Error())
+	}
+
+	if len(all) != 3 || all[0].data != "" || all[1].data != "a" || all[2].data != "b" {
+		t.Fatalf("Instances should be ordered by name, with the not named first")
+	}
+}
+
+func TestGetAllHonorsLifetimes(t *testing.T) {
+	var cont = godi.New()
+	godi.TransientNamed(cont, "b", func(c *godi.Container) *SomeStruct {
+		return &SomeStruct{data: "b"}
+	})
+	godi.Singleton(cont, func(c *godi.Container) *SomeStruct {
+		return &SomeStruct{data: ""}
+	})
+	godi.ScopedNamed(cont, "a", func(c *godi.Container) *SomeStruct {
+		return &SomeStruct{data: "a"}
+	})
+
+	x, _ := godi.GetAll[*SomeStruct](cont)
+	y, _ := godi.GetAll[*SomeStruct](cont)
+
+	if x[0] != y[0] || x[1] != y[1] {
+		t.Fatalf("Singleton and scoped should return the same instance")
+	}
+
+	if x[2] == y[2] {
+		t.Fatalf("Transient should return a new instance")
+	}
+
+	scoped, _ := godi.GetAll[*SomeStruct](cont.NewScope())
+
+	if x[0] != scoped[0] || x[1] == scoped[1] {
+		t.Fatalf("Scoped should return a new instance on a new scope")
+	}
+}
+
+func TestGetAllIncludesScopedRegistrations(t *testing.T) {
+	var cont = godi.New()
+	godi.TransientNamed(cont, "b", func(c *godi.Container) *SomeStruct {
+		return &SomeStruct{data: "b"}
+	})
+	godi.Singleton(cont, func(c *godi.Container) *SomeStruct {
+		return &SomeStruct{data: ""}
+	})
+	godi.ScopedNamed(cont, "a", func(c *godi.Container) *SomeStruct {
+		return &SomeStruct{data: "a"}
+	})
+
+	scope := cont.NewScope()
+	godi.ScopedNamed(scope, "c", func(c *godi.Container) *SomeStruct {
+		return &SomeStruct{data: "c"}
+	})
+
+	all, _ := godi.GetAll[*SomeStruct](scope)
+	if len(all) != 4 || all[3].data != "c" {
+		t.Fatalf("Registrations on the scope should be included")
+	}
+
+	all, _ = godi.GetAll[*SomeStruct](cont)
+	if len(all) != 3 {
+		t.Fatalf("Registrations on the scope should not be visible on the parent")
+	}
+}
+
+func TestGetAllAppliesDecorators(t *testing.T) {
+	var cont = godi.New()
+	godi.TransientNamed(cont, "1", func(c *godi.Container) Doer {
+		return &SimpleDoer{}
+	})
+	godi.TransientNamed(cont, "2", func(c *godi.Container) Doer {
+		return &SimpleDoer{}
+	})
+	godi.Decorate(cont, func(d Doer, c *godi.Container) Doer {
+		return &CallCountDecorator{d: d}
+	})
+
+	all, _ := godi.GetAll[Doer](cont)
+
+	for _, doer := range all {
+		if _, ok := doer.(*CallCountDecorator); !ok {
+			t.Fatalf("Every instance should be decorated")
+		}
+	}
+}
+
+func TestGetAllReturnsEmptyIfNotRegistered(t *testing.T) {
+	var cont = godi.New()
+
+	all, err := godi.GetAll[*SomeStruct](cont)
+	if err != nil || len(all) != 0 {
+		t.Fatalf("It should return no instances")
+	}
+}
+
+func TestGetMap(t *testing.T) {
+	var cont = godi.New()
+	godi.TransientNamed(cont, "b", func(c *godi.Container) *SomeStruct {
+		return &SomeStruct{data: "b"}
+	})
+	godi.Singleton(cont, func(c *godi.Container) *SomeStruct {
+		return &SomeStruct{data: ""}
+	})
+	godi.ScopedNamed(cont, "a", func(c *godi.Container) *SomeStruct {
+		return &SomeStruct{data: "a"}
+	})
+
+	all, err := godi.GetMap[*SomeStruct](cont)
+	if err != nil {
+		t.Fatalf("Failed to get instances: %v", err.Error())
+	}
+
+	if len(all) != 3 || all["a"].data != "a" || all["b"].data != "b" || all[""] == nil {
+		t.Fatalf("Instances should be indexed by name")
+	}
+}
+
+func TestGetAllErrorOnClosedScope(t *testing.T) {
+	var cont = godi.New()
+	scope := cont.NewScope()
+	scope.Close()
+
+	if _, err := godi.GetAll[*SomeStruct](scope); !errors.Is(err, godi.ErrScopeClosed) {
+		t.Fatalf("Expecting scope closed, got: %v", err)
+	}
+
+	if _, err := godi.GetMap[*SomeStruct](scope); !errors.Is(err, godi.ErrScopeClosed) {
+		t.Fatalf("Expecting scope closed, got: %v", err)
+	}
+}
@@ -0,0 +1,152 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mingue/godi"
+)
+
+type (
+	Repository interface {
+		Name() string
+	}
+	RepositoryImpl struct {
+		name string
+	}
+	Service struct {
+		repo Repository
+		some *SomeStruct
+	}
+)
+
+func (r *RepositoryImpl) Name() string {
+	return r.name
+}
+
+func NewRepositoryImpl() *RepositoryImpl {
+	return &RepositoryImpl{name: "repo"}
+}
+
+func NewService(repo Repository, some *SomeStruct) *Service {
+	return &Service{repo: repo, some: some}
+}
+
+func TestProvideResolvesConstructorParameters(t *testing.T) {
+	var cont = godi.New()
+	godi.ProvideAs[Repository](cont, godi.LifetimeSingleton, NewRepositoryImpl)
+	godi.Provide(cont, godi.LifetimeTransient, func() *SomeStruct {
+		return &SomeStruct{data: "some"}
+	})
+	godi.Provide(cont, godi.LifetimeTransient, NewService)
+
+	svc, err := godi.Get[*Service](cont)
+	if err != nil {
+		t.Fatalf("Failed to get instance: %v", err.Error())
+	}
+
+	if svc.repo.Name() != "repo" || svc.some.data != "some" {
+		t.Fatalf("Parameters should be resolved from the container")
+	}
+}
+
+func TestProvideHonorsLifetime(t *testing.T) {
+	var cont = godi.New()
+	godi.Provide(cont, godi.LifetimeSingleton, func() *SomeStruct {
+		return &SomeStruct{}
+	})
+
+	x, _ := godi.Get[*SomeStruct](cont)
+	y, _ := godi.Get[*SomeStruct](cont.NewScope())
+
+	if x != y {
+		t.Fatalf("It should be the same instance")
+	}
+}
+
+func TestProvideReturnsConstructorError(t *testing.T) {
+	var cont = godi.New()
+	godi.Provide(cont, godi.LifetimeTransient, func() (*SomeStruct, error) {
+		return nil, errFactoryFailed
+	})
+
+	_, err := godi.Get[*SomeStruct](cont)
+	if !errors.Is(err, errFactoryFailed) {
+		t.Fatalf("Expecting constructor error, got: %v", err)
+	}
+}
+
+func TestProvideReturnsErrorIfParameterNotRegistered(t *testing.T) {
+	var cont = godi.New()
+	godi.Provide(cont, godi.LifetimeTransient, NewService)
+
+	_, err := godi.Get[*Service](cont)
+	if !errors.Is(err, godi.ErrFactoryNotRegistered) {
+		t.Fatalf("Expecting factory not registered, got: %v", err)
+	}
+}
+
+func TestProvideNamedPassesContainer(t *testing.T) {
+	var cont = godi.New()
+	var received *godi.Container
+	godi.ProvideAs[Repository](cont, godi.LifetimeScoped, NewRepositoryImpl)
+	godi.ProvideNamed(cont, "name", godi.LifetimeTransient, func(c *godi.Container) *SomeStruct {
+		received = c
+		return &SomeStruct{}
+	})
+
+	scope := cont.NewScope()
+
+	if _, err := godi.GetNamed[*SomeStruct](scope, "name"); err != nil {
+		t.Fatalf("Failed to get instance: %v", err.Error())
+	}
+
+	// The constructor receives a copy of the scope tracking the registration being built,
+	// which shares the registrations and instances of the scope
+	x, _ := godi.Get[Repository](received)
+	y, _ := godi.Get[Repository](scope)
+
+	if received == nil || x != y {
+		t.Fatalf("The container should be passed to the constructor")
+	}
+
+	received.Close()
+
+	if _, err := godi.Get[Repository](scope); !errors.Is(err, godi.ErrScopeClosed) {
+		t.Fatalf("Closing the container passed to the constructor should close the scope, got: %v", err)
+	}
+}
+
+func TestProvideRejectsInvalidConstructors(t *testing.T) {
+	var cont = godi.New()
+	invalid := []any{
+		nil,
+		"not a func",
+		func() {},
+		func() (*SomeStruct, string) { return nil, "" },
+		func(...int) *SomeStruct { return nil },
+	}
+
+	for _, ctor := range invalid {
+		if err := godi.Provide(cont, godi.LifetimeTransient, ctor); !errors.Is(err, godi.ErrInvalidConstructor) {
+			t.Fatalf("Expecting invalid constructor for %T, got: %v", ctor, err)
+		}
+	}
+
+	if err := godi.ProvideAs[Repository](cont, godi.LifetimeTransient, func() *SomeStruct { return nil }); !errors.Is(err, godi.ErrInvalidConstructor) {
+		t.Fatalf("Expecting invalid constructor when not assignable, got: %v", err)
+	}
+}
+
+func TestProvideRejectsUnknownLifetimes(t *testing.T) {
+	var cont = godi.New()
+
+	err := godi.Provide(cont, "Singelton", NewRepositoryImpl)
+	if !errors.Is(err, godi.ErrInvalidLifetime) {
+		t.Fatalf("Expecting invalid lifetime, got: %v", err)
+	}
+
+	if _, err := godi.Get[*RepositoryImpl](cont); !errors.Is(err, godi.ErrFactoryNotRegistered) {
+		t.Fatalf("The constructor should not be registered, got: %v", err)
+	}
+}
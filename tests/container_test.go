@@ -1,6 +1,7 @@
 package test
 
 import (
+	"errors"
 	"log"
 	"testing"
 
@@ -11,7 +12,7 @@ func TestGetErrorIfNotRegistered(t *testing.T) {
 	var cont = godi.New()
 	_, err := godi.Get[SomeInterface](cont)
 
-	if err == nil || err != godi.ErrFactoryNotRegistered {
+	if !errors.Is(err, godi.ErrFactoryNotRegistered) {
 		log.Fatal("Expecting factory not registered")
 	}
 }
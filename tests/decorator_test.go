@@ -1,8 +1,12 @@
 package test
 
 import (
+	"errors"
+	"fmt"
 	"log"
+	"runtime"
 	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/mingue/godi"
@@ -33,7 +37,7 @@ func (d *CallCountDecorator) Do() {
 	d.postCount++
 }
 
-func TestDecorateErrorsIfFactoryNotRegisteredPreviously(t *testing.T) {
+func TestDecorateAppliesToFactoriesRegisteredAfterwards(t *testing.T) {
 	var cont = godi.New()
 
 	err := godi.Decorate(cont, func(d Doer, c *godi.Container) Doer {
@@ -41,9 +45,27 @@ func TestDecorateErrorsIfFactoryNotRegisteredPreviously(t *testing.T) {
 			d: d,
 		}
 	})
+	if err != nil {
+		t.Fatalf("Decorators should be accepted before the factory: %v", err.Error())
+	}
+
+	godi.Transient(cont, func(c *godi.Container) Doer {
+		return &SimpleDoer{}
+	})
+	godi.SingletonNamed(cont, "singleton", func(c *godi.Container) Doer {
+		return &SimpleDoer{}
+	})
+	godi.InstanceNamed[Doer](cont, "instance", &SimpleDoer{})
+
+	all, err := godi.GetAll[Doer](cont)
+	if err != nil || len(all) != 3 {
+		t.Fatalf("Failed to get instances: %v", err)
+	}
 
-	if err == nil || err != godi.ErrDecoratorBeforeFactory {
-		log.Fatal("Expecting factory first error")
+	for _, doer := range all {
+		if _, ok := doer.(*CallCountDecorator); !ok {
+			t.Fatalf("Every factory should be decorated no matter the registration order")
+		}
 	}
 }
 
@@ -140,3 +162,306 @@ func TestDecorateNamedDefinitions(t *testing.T) {
 		}
 	}
 }
+
+func TestScopeDecoratorsDoNotAccumulateOnRequestScopes(t *testing.T) {
+	var cont = godi.New()
+	godi.Transient(cont, func(c *godi.Container) Doer {
+		return &SimpleDoer{}
+	})
+
+	for i := 0; i < 3; i++ {
+		scope := cont.NewScope()
+
+		err := godi.Decorate(scope, func(d Doer, c *godi.Container) Doer {
+			return &CallCountDecorator{d: d}
+		})
+		if err != nil {
+			t.Fatalf("Failed to decorate: %v", err.Error())
+		}
+
+		x, _ := godi.Get[Doer](scope)
+		decorated, ok := x.(*CallCountDecorator)
+		if !ok {
+			t.Fatalf("Instances of the scope should be decorated")
+		}
+
+		if _, ok := decorated.d.(*SimpleDoer); !ok {
+			t.Fatalf("Decorators of previous scopes should not be applied")
+		}
+	}
+
+	x, _ := godi.Get[Doer](cont)
+	if _, ok := x.(*SimpleDoer); !ok {
+		t.Fatalf("Scope decorators should not be applied to the container")
+	}
+}
+
+func TestScopeDecoratorsWrapSharedSingletons(t *testing.T) {
+	var cont = godi.New()
+	godi.Singleton(cont, func(c *godi.Container) Doer {
+		return &SimpleDoer{}
+	})
+
+	scope := cont.NewScope()
+	godi.Decorate(scope, func(d Doer, c *godi.Container) Doer {
+		return &CallCountDecorator{d: d}
+	})
+
+	x, _ := godi.Get[Doer](scope)
+	y, _ := godi.Get[Doer](scope.NewScope())
+	singleton, _ := godi.Get[Doer](cont)
+
+	decorated, ok := x.(*CallCountDecorator)
+	if !ok || decorated.d != singleton {
+		t.Fatalf("Scope decorators should wrap the singleton")
+	}
+
+	if y.(*CallCountDecorator).d != singleton {
+		t.Fatalf("Scope decorators should apply to nested scopes")
+	}
+
+	if z, _ := godi.Get[Doer](scope); z != x {
+		t.Fatalf("Decorated singletons should be cached on the scope")
+	}
+}
+
+func TestDecorateNamed(t *testing.T) {
+	var cont = godi.New()
+	godi.TransientNamed(cont, "/", func(c *godi.Container) Doer {
+		return &SimpleDoer{}
+	})
+	godi.TransientNamed(cont, "/ready", func(c *godi.Container) Doer {
+		return &SimpleDoer{}
+	})
+	godi.DecorateNamed(cont, "/", func(d Doer, c *godi.Container) Doer {
+		return &CallCountDecorator{d: d}
+	})
+	cont.Freeze()
+
+	x, _ := godi.GetNamed[Doer](cont, "/")
+	if _, ok := x.(*CallCountDecorator); !ok {
+		t.Fatalf("The named registration should be decorated")
+	}
+
+	y, _ := godi.GetNamed[Doer](cont, "/ready")
+	if _, ok := y.(*SimpleDoer); !ok {
+		t.Fatalf("Other registrations should not be decorated")
+	}
+}
+
+func TestDecorateWhenTagged(t *testing.T) {
+	var cont = godi.New()
+	godi.TransientNamed(cont, "a", func(c *godi.Container) Doer {
+		return &SimpleDoer{}
+	})
+	godi.SingletonNamed(cont, "b", func(c *godi.Container) Doer {
+		return &SimpleDoer{}
+	})
+	godi.TagNamed[Doer](cont, "a", "auth")
+
+	godi.DecorateWhen(cont, func(r godi.Registration) bool {
+		for _, tag := range r.Tags {
+			if tag == "auth" {
+				return true
+			}
+		}
+
+		return r.Lifetime == godi.LifetimeSingleton
+	}, func(d Doer, c *godi.Container) Doer {
+		return &CallCountDecorator{d: d}
+	})
+
+	all, _ := godi.GetAll[Doer](cont)
+	for _, doer := range all {
+		if _, ok := doer.(*CallCountDecorator); !ok {
+			t.Fatalf("Registrations matching the predicate should be decorated")
+		}
+	}
+
+	if err := godi.Tag[Doer](cont, "auth"); err != godi.ErrFactoryNotRegistered {
+		t.Fatalf("Expecting factory not registered, got: %v", err)
+	}
+}
+
+type NamedDecorator struct {
+	d    Doer
+	name string
+}
+
+func (d *NamedDecorator) Do() {
+	d.d.Do()
+}
+
+func decorateWithName(name string) func(d Doer, c *godi.Container) Doer {
+	return func(d Doer, c *godi.Container) Doer {
+		return &NamedDecorator{d: d, name: name}
+	}
+}
+
+// Returns the names of the decorators from the outermost to the innermost
+func decoratorNames(d Doer) []string {
+	var names []string
+
+	for decorated, ok := d.(*NamedDecorator); ok; decorated, ok = decorated.d.(*NamedDecorator) {
+		names = append(names, decorated.name)
+	}
+
+	return names
+}
+
+func TestDecoratorOrder(t *testing.T) {
+	var cont = godi.New()
+	godi.Transient(cont, func(c *godi.Container) Doer {
+		return &SimpleDoer{}
+	})
+	godi.SingletonNamed(cont, "singleton", func(c *godi.Container) Doer {
+		return &SimpleDoer{}
+	})
+	godi.InstanceNamed[Doer](cont, "instance", &SimpleDoer{})
+	godi.Decorate(cont, decorateWithName("recovery"), godi.WithOrder(10))
+	godi.Decorate(cont, decorateWithName("logging"))
+	godi.Decorate(cont, decorateWithName("metrics"))
+	godi.Decorate(cont, decorateWithName("tracing"), godi.WithOrder(5))
+
+	scope := cont.NewScope()
+	godi.Decorate(scope, decorateWithName("request"), godi.WithOrder(-1))
+
+	x, _ := godi.Get[Doer](scope)
+	names := strings.Join(decoratorNames(x), ",")
+
+	if names != "recovery,tracing,metrics,logging,request" {
+		t.Fatalf("Decorators should be applied by order, got: %v", names)
+	}
+
+	// Singletons shared by every scope are decorated again by the scope honoring the order
+	for _, name := range []string{"singleton", "instance"} {
+		x, _ := godi.GetNamed[Doer](scope, name)
+		names := strings.Join(decoratorNames(x), ",")
+
+		if names != "recovery,tracing,metrics,logging,request" {
+			t.Fatalf("Decorators of %v should be applied by order, got: %v", name, names)
+		}
+
+		y, _ := godi.GetNamed[Doer](cont, name)
+		names = strings.Join(decoratorNames(y), ",")
+
+		if names != "recovery,tracing,metrics,logging" {
+			t.Fatalf("Decorators of the scope should not apply to the container for %v, got: %v", name, names)
+		}
+	}
+}
+
+type Job func() string
+
+func TestDecorateFunctionsAndPointersWhenAllowed(t *testing.T) {
+	var cont = godi.New()
+	godi.Singleton(cont, func(c *godi.Container) Job {
+		return func() string { return "job" }
+	})
+	godi.Transient(cont, func(c *godi.Container) *SomeStruct {
+		return &SomeStruct{data: "some"}
+	})
+
+	err := godi.Decorate(cont, func(j Job, c *godi.Container) Job {
+		return func() string { return "decorated " + j() }
+	})
+	if err != godi.ErrDecoratedMustBeInterface {
+		t.Fatalf("Expecting decorated must be interface by default, got: %v", err)
+	}
+
+	err = godi.Decorate(cont, func(j Job, c *godi.Container) Job {
+		return func() string { return "decorated " + j() }
+	}, godi.AllowNonInterface())
+	if err != nil {
+		t.Fatalf("Failed to decorate function: %v", err.Error())
+	}
+
+	err = godi.Decorate(cont, func(s *SomeStruct, c *godi.Container) *SomeStruct {
+		return &SomeStruct{data: "decorated " + s.data}
+	}, godi.AllowNonInterface())
+	if err != nil {
+		t.Fatalf("Failed to decorate pointer: %v", err.Error())
+	}
+
+	job, _ := godi.Get[Job](cont)
+	some, _ := godi.Get[*SomeStruct](cont)
+
+	if job() != "decorated job" || some.data != "decorated some" {
+		t.Fatalf("Functions and pointers should be decorated")
+	}
+
+	err = godi.Decorate(cont, func(s SomeStruct, c *godi.Container) SomeStruct {
+		return s
+	}, godi.AllowNonInterface())
+	if err != godi.ErrDecoratedMustBeInterface {
+		t.Fatalf("Expecting decorated must be interface for structs, got: %v", err)
+	}
+}
+
+func TestGetUndecorated(t *testing.T) {
+	var cont = godi.New()
+	var calls int
+	godi.Singleton(cont, func(c *godi.Container) Doer {
+		calls++
+		return &SimpleDoer{}
+	})
+	godi.TransientNamed(cont, "transient", func(c *godi.Container) Doer {
+		return &SimpleDoer{}
+	})
+	godi.Decorate(cont, func(d Doer, c *godi.Container) Doer {
+		return &CallCountDecorator{d: d}
+	})
+
+	raw, err := godi.GetUndecorated[Doer](cont)
+	if err != nil {
+		t.Fatalf("Failed to get instance: %v", err.Error())
+	}
+
+	decorated, _ := godi.Get[Doer](cont)
+	if decorated.(*CallCountDecorator).d != raw {
+		t.Fatalf("The undecorated instance should be the one wrapped by the decorators")
+	}
+
+	if calls != 1 {
+		t.Fatalf("Singletons should be built once, built: %v", calls)
+	}
+
+	transient, _ := godi.GetNamedUndecorated[Doer](cont.NewScope(), "transient")
+	if _, ok := transient.(*SimpleDoer); !ok {
+		t.Fatalf("Transient instances should be built without decorators")
+	}
+}
+
+func TestDecorateEReturnsErrorWithRegistrationSite(t *testing.T) {
+	var cont = godi.New()
+	var fail = true
+	godi.Singleton(cont, func(c *godi.Container) Doer {
+		return &SimpleDoer{}
+	})
+
+	_, file, line, _ := runtime.Caller(0)
+	godi.DecorateE(cont, func(d Doer, c *godi.Container) (Doer, error) {
+		if fail {
+			return nil, errFactoryFailed
+		}
+
+		return &CallCountDecorator{d: d}, nil
+	})
+
+	_, err := godi.Get[Doer](cont)
+	if !errors.Is(err, errFactoryFailed) {
+		t.Fatalf("Expecting the decorator error, got: %v", err)
+	}
+
+	site := fmt.Sprintf("%v:%v", file, line+1)
+	if !strings.Contains(err.Error(), site) {
+		t.Fatalf("Error should contain the registration site %v: %v", site, err.Error())
+	}
+
+	fail = false
+
+	x, err := godi.Get[Doer](cont)
+	if _, ok := x.(*CallCountDecorator); !ok || err != nil {
+		t.Fatalf("Failed decorations should be retried, got: %v", err)
+	}
+}
@@ -0,0 +1,292 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mingue/godi"
+)
+
+type (
+	Closable struct {
+		name   string
+		closed *[]string
+		err    error
+	}
+	Disposable struct {
+		Closable
+		ctx context.Context
+	}
+	ClosableDependency struct {
+		Closable
+	}
+	BlockingDisposable struct{}
+)
+
+func (c *Closable) Close() error {
+	*c.closed = append(*c.closed, c.name)
+	return c.err
+}
+
+func (d *Disposable) Dispose(ctx context.Context) error {
+	d.ctx = ctx
+	*d.closed = append(*d.closed, d.name+" disposed")
+	return d.err
+}
+
+func (d *BlockingDisposable) Dispose(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestCloseDisposesScopedInstancesInReverseOrder(t *testing.T) {
+	var cont = godi.New()
+	closed := []string{}
+
+	godi.Scoped(cont, func(c *godi.Container) *ClosableDependency {
+		return &ClosableDependency{Closable{name: "dependency", closed: &closed}}
+	})
+	godi.Scoped(cont, func(c *godi.Container) *Closable {
+		godi.Get[*ClosableDependency](c)
+		return &Closable{name: "dependant", closed: &closed}
+	})
+	godi.Scoped(cont, func(c *godi.Container) *Disposable {
+		return &Disposable{Closable: Closable{name: "disposable", closed: &closed}}
+	})
+
+	scope := cont.NewScope()
+	godi.Get[*Closable](scope)
+	disposable, _ := godi.Get[*Disposable](scope)
+
+	err := scope.Close()
+	if err != nil {
+		t.Fatalf("Failed to close: %v", err.Error())
+	}
+
+	if len(closed) != 3 || closed[0] != "disposable disposed" || closed[1] != "dependant" || closed[2] != "dependency" {
+		t.Fatalf("Instances should be disposed in reverse creation order: %v", closed)
+	}
+
+	if disposable.ctx == nil {
+		t.Fatalf("Disposer should receive a context")
+	}
+}
+
+func TestCloseOnlyDisposesTheScope(t *testing.T) {
+	var cont = godi.New()
+	closed := []string{}
+
+	godi.Singleton(cont, func(c *godi.Container) *ClosableDependency {
+		return &ClosableDependency{Closable{name: "singleton", closed: &closed}}
+	})
+	godi.Scoped(cont, func(c *godi.Container) *Closable {
+		return &Closable{name: "scoped", closed: &closed}
+	})
+
+	firstScope := cont.NewScope()
+	secondScope := cont.NewScope()
+	godi.Get[*ClosableDependency](firstScope)
+	godi.Get[*Closable](firstScope)
+	godi.Get[*Closable](secondScope)
+
+	firstScope.Close()
+
+	if len(closed) != 1 || closed[0] != "scoped" {
+		t.Fatalf("Only scoped instances of the scope should be disposed: %v", closed)
+	}
+
+	if _, err := godi.Get[*Closable](secondScope); err != nil {
+		t.Fatalf("Other scopes should not be closed: %v", err.Error())
+	}
+}
+
+func TestCloseAggregatesErrors(t *testing.T) {
+	var cont = godi.New()
+	closed := []string{}
+	errFirst := errors.New("first")
+	errSecond := errors.New("second")
+
+	godi.ScopedNamed(cont, "1", func(c *godi.Container) *Closable {
+		return &Closable{name: "1", closed: &closed, err: errFirst}
+	})
+	godi.ScopedNamed(cont, "2", func(c *godi.Container) *Closable {
+		return &Closable{name: "2", closed: &closed, err: errSecond}
+	})
+
+	scope := cont.NewScope()
+	godi.GetAll[*Closable](scope)
+
+	err := scope.Close()
+	if !errors.Is(err, errFirst) || !errors.Is(err, errSecond) {
+		t.Fatalf("Expecting all dispose errors, got: %v", err)
+	}
+
+	if len(closed) != 2 {
+		t.Fatalf("All instances should be disposed even if one fails: %v", closed)
+	}
+}
+
+func TestGetErrorOnClosedScope(t *testing.T) {
+	var cont = godi.New()
+	closed := []string{}
+	godi.Scoped(cont, func(c *godi.Container) *Closable {
+		return &Closable{name: "scoped", closed: &closed}
+	})
+
+	scope := cont.NewScope()
+	godi.Get[*Closable](scope)
+	scope.Close()
+
+	if _, err := godi.Get[*Closable](scope); !errors.Is(err, godi.ErrScopeClosed) {
+		t.Fatalf("Expecting scope closed, got: %v", err)
+	}
+
+	var x *Closable
+	if err := godi.GetNoAlloc(scope, &x); !errors.Is(err, godi.ErrScopeClosed) {
+		t.Fatalf("Expecting scope closed, got: %v", err)
+	}
+
+	if err := scope.Close(); err != nil || len(closed) != 1 {
+		t.Fatalf("Closing again should do nothing")
+	}
+}
+
+func TestShutdownDisposesSingletonsInReverseOrder(t *testing.T) {
+	var cont = godi.New()
+	closed := []string{}
+
+	godi.Singleton(cont, func(c *godi.Container) *ClosableDependency {
+		return &ClosableDependency{Closable{name: "dependency", closed: &closed}}
+	})
+	godi.Singleton(cont, func(c *godi.Container) *Closable {
+		godi.Get[*ClosableDependency](c)
+		return &Closable{name: "dependant", closed: &closed}
+	})
+	godi.Scoped(cont, func(c *godi.Container) *Disposable {
+		return &Disposable{Closable: Closable{name: "scoped", closed: &closed}}
+	})
+	godi.Instance(cont, &Disposable{Closable: Closable{name: "instance", closed: &closed}})
+
+	scope := cont.NewScope()
+	godi.Get[*Closable](scope)
+	godi.Get[*Disposable](scope)
+
+	err := cont.Shutdown(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to shutdown: %v", err.Error())
+	}
+
+	if len(closed) != 2 || closed[0] != "dependant" || closed[1] != "dependency" {
+		t.Fatalf("Only built singletons should be disposed in reverse creation order: %v", closed)
+	}
+
+	if _, err := godi.Get[*Closable](cont); !errors.Is(err, godi.ErrContainerShutdown) {
+		t.Fatalf("Expecting container shutdown, got: %v", err)
+	}
+}
+
+func TestShutdownIsIdempotent(t *testing.T) {
+	var cont = godi.New()
+	closed := []string{}
+	godi.Singleton(cont, func(c *godi.Container) *Closable {
+		return &Closable{name: "singleton", closed: &closed}
+	})
+	godi.Get[*Closable](cont)
+
+	cont.Shutdown(context.Background())
+	err := cont.Shutdown(context.Background())
+
+	if err != nil || len(closed) != 1 {
+		t.Fatalf("Shutdown should only dispose once")
+	}
+}
+
+func TestShutdownReportsInstancesNotDisposedBeforeDeadline(t *testing.T) {
+	var cont = godi.New()
+	closed := []string{}
+	godi.Singleton(cont, func(c *godi.Container) *Closable {
+		return &Closable{name: "singleton", closed: &closed}
+	})
+	godi.Singleton(cont, func(c *godi.Container) *BlockingDisposable {
+		return &BlockingDisposable{}
+	})
+	godi.Get[*Closable](cont)
+	godi.Get[*BlockingDisposable](cont)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := cont.Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expecting deadline exceeded, got: %v", err)
+	}
+
+	if !strings.Contains(err.Error(), "BlockingDisposable") || !strings.Contains(err.Error(), "Closable") {
+		t.Fatalf("Error should list the instances not disposed: %v", err.Error())
+	}
+
+	if len(closed) != 0 {
+		t.Fatalf("Instances after the deadline should not be disposed: %v", closed)
+	}
+}
+
+func TestShutdownErrorOnScope(t *testing.T) {
+	var cont = godi.New()
+
+	if err := cont.NewScope().Shutdown(context.Background()); err != godi.ErrShutdownOnScope {
+		t.Fatalf("Expecting shutdown on scope, got: %v", err)
+	}
+}
+
+func TestCloseErrorOnContainer(t *testing.T) {
+	var cont = godi.New()
+	godi.Singleton(cont, func(c *godi.Container) *Closable {
+		return &Closable{}
+	})
+
+	if err := cont.Close(); err != godi.ErrCloseOnContainer {
+		t.Fatalf("Expecting close on container, got: %v", err)
+	}
+
+	if _, err := godi.Get[*Closable](cont); err != nil {
+		t.Fatalf("The container should keep resolving: %v", err)
+	}
+}
+
+func TestShutdownDisposesInstancesBeingBuilt(t *testing.T) {
+	var cont = godi.New()
+	closed := []string{}
+	building := make(chan struct{})
+	release := make(chan struct{})
+
+	godi.Singleton(cont, func(c *godi.Container) *Closable {
+		close(building)
+		<-release
+		return &Closable{name: "in flight", closed: &closed}
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := godi.Get[*Closable](cont)
+		done <- err
+	}()
+
+	<-building
+
+	if err := cont.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Failed to shutdown: %v", err.Error())
+	}
+
+	close(release)
+
+	if err := <-done; !errors.Is(err, godi.ErrContainerShutdown) {
+		t.Fatalf("Expecting container shutdown, got: %v", err)
+	}
+
+	if len(closed) != 1 {
+		t.Fatalf("The instance built during shutdown should be disposed: %v", closed)
+	}
+}
@@ -0,0 +1,165 @@
+package test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mingue/godi"
+)
+
+var errFactoryFailed = errors.New("factory failed")
+
+func TestGetReturnsFactoryError(t *testing.T) {
+	var cont = godi.New()
+	godi.TransientE(cont, func(c *godi.Container) (*SomeStruct, error) {
+		return nil, errFactoryFailed
+	})
+
+	_, err := godi.Get[*SomeStruct](cont)
+	if !errors.Is(err, errFactoryFailed) {
+		t.Fatalf("Expecting factory error, got: %v", err)
+	}
+
+	if !strings.Contains(err.Error(), "SomeStruct") {
+		t.Fatalf("Error should contain the type being built: %v", err.Error())
+	}
+}
+
+func TestGetNamedReturnsFactoryErrorWithName(t *testing.T) {
+	var cont = godi.New()
+	godi.ScopedNamedE(cont, "name", func(c *godi.Container) (*SomeStruct, error) {
+		return nil, errFactoryFailed
+	})
+
+	_, err := godi.GetNamed[*SomeStruct](cont, "name")
+	if !errors.Is(err, errFactoryFailed) {
+		t.Fatalf("Expecting factory error, got: %v", err)
+	}
+
+	if !strings.Contains(err.Error(), `"name"`) {
+		t.Fatalf("Error should contain the name being built: %v", err.Error())
+	}
+}
+
+func TestGetNoAllocReturnsFactoryError(t *testing.T) {
+	var cont = godi.New()
+	godi.SingletonE(cont, func(c *godi.Container) (*SomeStruct, error) {
+		return nil, errFactoryFailed
+	})
+
+	var x *SomeStruct
+	err := godi.GetNoAlloc(cont, &x)
+	if !errors.Is(err, errFactoryFailed) {
+		t.Fatalf("Expecting factory error, got: %v", err)
+	}
+}
+
+func TestGetPropagatesDependencyFactoryError(t *testing.T) {
+	var cont = godi.New()
+	godi.TransientE(cont, func(c *godi.Container) (SomeInterface, error) {
+		return nil, errFactoryFailed
+	})
+	godi.TransientE(cont, func(c *godi.Container) (*SomeStruct, error) {
+		_, err := godi.Get[SomeInterface](c)
+		if err != nil {
+			return nil, err
+		}
+
+		return &SomeStruct{}, nil
+	})
+
+	_, err := godi.Get[*SomeStruct](cont)
+	if !errors.Is(err, errFactoryFailed) {
+		t.Fatalf("Expecting dependency factory error, got: %v", err)
+	}
+}
+
+func TestFailedSingletonIsNotCached(t *testing.T) {
+	var cont = godi.New()
+	calls := 0
+	godi.SingletonE(cont, func(c *godi.Container) (*SomeStruct, error) {
+		calls++
+		if calls == 1 {
+			return nil, errFactoryFailed
+		}
+
+		return &SomeStruct{}, nil
+	})
+
+	_, err := godi.Get[*SomeStruct](cont)
+	if err == nil {
+		t.Fatal("First call should fail")
+	}
+
+	x, err := godi.Get[*SomeStruct](cont)
+	if err != nil {
+		t.Fatalf("Second call should be retried: %v", err.Error())
+	}
+
+	y, _ := godi.Get[*SomeStruct](cont)
+	if x != y || calls != 2 {
+		t.Fatalf("Successful instance should be cached, calls: %v", calls)
+	}
+}
+
+func TestFailedScopedIsNotCached(t *testing.T) {
+	var cont = godi.New()
+	calls := 0
+	godi.ScopedE(cont, func(c *godi.Container) (*SomeStruct, error) {
+		calls++
+		if calls == 1 {
+			return nil, errFactoryFailed
+		}
+
+		return &SomeStruct{}, nil
+	})
+
+	var x *SomeStruct
+	if err := godi.GetNoAlloc(cont, &x); err == nil {
+		t.Fatal("First call should fail")
+	}
+
+	if err := godi.GetNoAlloc(cont, &x); err != nil || x == nil {
+		t.Fatalf("Second call should be retried: %v", err)
+	}
+}
+
+func TestFactoryPanicIsReturnedAsErrorAndRetried(t *testing.T) {
+	var cont = godi.New()
+	var calls int
+	godi.Singleton(cont, func(c *godi.Container) *SomeStruct {
+		calls++
+		if calls == 1 {
+			var nilStruct *SomeStruct
+			_ = nilStruct.data
+		}
+
+		return &SomeStruct{}
+	})
+
+	_, err := godi.Get[*SomeStruct](cont)
+	if !errors.Is(err, godi.ErrFactoryPanicked) {
+		t.Fatalf("Expecting factory panicked, got: %v", err)
+	}
+
+	x, err := godi.Get[*SomeStruct](cont)
+	if err != nil || x == nil {
+		t.Fatalf("Singletons should be retried after a panic, got: %v", err)
+	}
+}
+
+func TestDecoratorPanicIsReturnedAsError(t *testing.T) {
+	var cont = godi.New()
+	godi.Transient(cont, func(c *godi.Container) Doer {
+		return &SimpleDoer{}
+	})
+	godi.Decorate(cont, func(d Doer, c *godi.Container) Doer {
+		panic("decorator failed")
+	})
+
+	_, err := godi.Get[Doer](cont)
+	if !errors.Is(err, godi.ErrFactoryPanicked) || !strings.Contains(err.Error(), "decorator failed") {
+		t.Fatalf("Expecting factory panicked, got: %v", err)
+	}
+}
@@ -0,0 +1,234 @@
+package test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mingue/godi"
+)
+
+func TestGetOnFrozenContainer(t *testing.T) {
+	var cont = godi.New()
+	godi.ProvideAs[Repository](cont, godi.LifetimeSingleton, NewRepositoryImpl)
+	godi.Scoped(cont, func(c *godi.Container) *SomeStruct {
+		return &SomeStruct{}
+	})
+	godi.Provide(cont, godi.LifetimeTransient, NewService)
+	godi.InstanceNamed(cont, "instance", &SomeStruct{data: "instance"})
+
+	before, _ := godi.Get[Repository](cont)
+
+	if err := cont.Freeze(); err != nil {
+		t.Fatalf("Failed to freeze: %v", err.Error())
+	}
+
+	scope := cont.NewScope()
+	svc, err := godi.Get[*Service](scope)
+	if err != nil {
+		t.Fatalf("Failed to get instance: %v", err.Error())
+	}
+
+	if svc.repo != before {
+		t.Fatalf("Singletons built before freezing should be kept")
+	}
+
+	some, _ := godi.Get[*SomeStruct](scope)
+	if svc.some != some {
+		t.Fatalf("Scoped should return the same instance on the same scope")
+	}
+
+	other, _ := godi.Get[*SomeStruct](cont.NewScope())
+	if other == some {
+		t.Fatalf("Scoped should return a different instance on a different scope")
+	}
+
+	instance, _ := godi.GetNamed[*SomeStruct](scope, "instance")
+	if instance.data != "instance" {
+		t.Fatalf("Instances should be kept")
+	}
+}
+
+func TestScopedOnFrozenContainer(t *testing.T) {
+	var cont = godi.New()
+	closed := []string{}
+	godi.Scoped(cont, func(c *godi.Container) *Closable {
+		return &Closable{name: "scoped", closed: &closed}
+	})
+
+	before, _ := godi.Get[*Closable](cont)
+	cont.Freeze()
+
+	if after, _ := godi.Get[*Closable](cont); after != before {
+		t.Fatalf("Scoped instances built before freezing should be kept")
+	}
+
+	scope := cont.NewScope()
+	x, _ := godi.Get[*Closable](scope)
+
+	nested := scope.NewScope()
+	godi.Inherit[*Closable](nested)
+
+	if y, _ := godi.Get[*Closable](nested); x != y {
+		t.Fatalf("Inherited scoped instances should be shared with the nested scope")
+	}
+
+	if z, _ := godi.Get[*Closable](scope); x != z {
+		t.Fatalf("Scoped should return the same instance on the same scope")
+	}
+
+	scope.Close()
+
+	if len(closed) != 1 {
+		t.Fatalf("Scoped instances should be disposed on close: %v", closed)
+	}
+}
+
+func TestRegistrationErrorOnFrozenContainer(t *testing.T) {
+	var cont = godi.New()
+	godi.Transient(cont, func(c *godi.Container) Doer {
+		return &SimpleDoer{}
+	})
+	cont.Freeze()
+
+	err := godi.Singleton(cont, func(c *godi.Container) *SomeStruct {
+		return &SomeStruct{}
+	})
+	if err != godi.ErrContainerFrozen {
+		t.Fatalf("Expecting container frozen, got: %v", err)
+	}
+
+	if err := godi.Instance(cont, 1); err != godi.ErrContainerFrozen {
+		t.Fatalf("Expecting container frozen, got: %v", err)
+	}
+
+	err = godi.Transient(cont, func(c *godi.Container) Doer {
+		return &SimpleDoer{}
+	})
+	if err != godi.ErrContainerFrozen {
+		t.Fatalf("Expecting container frozen for already registered types, got: %v", err)
+	}
+
+	err = godi.Decorate(cont, func(d Doer, c *godi.Container) Doer {
+		return d
+	})
+	if err != godi.ErrContainerFrozen {
+		t.Fatalf("Expecting container frozen, got: %v", err)
+	}
+
+	if _, err := godi.Get[*SomeStruct](cont); !errors.Is(err, godi.ErrFactoryNotRegistered) {
+		t.Fatalf("Rejected registrations should not be available, got: %v", err)
+	}
+}
+
+func TestScopesCanRegisterOnFrozenContainer(t *testing.T) {
+	var cont = godi.New()
+	cont.Freeze()
+
+	scope := cont.NewScope()
+	err := godi.Scoped(scope, func(c *godi.Container) *SomeStruct {
+		return &SomeStruct{data: "scoped"}
+	})
+	if err != nil {
+		t.Fatalf("Failed to register on scope: %v", err.Error())
+	}
+
+	x, err := godi.Get[*SomeStruct](scope)
+	if err != nil || x.data != "scoped" {
+		t.Fatalf("Failed to get instance: %v", err)
+	}
+}
+
+func TestFreezeValidatesDependencies(t *testing.T) {
+	var cont = godi.New()
+	godi.Provide(cont, godi.LifetimeTransient, NewService)
+	godi.AutoWire[*MissingFieldsHandler](cont, godi.LifetimeTransient)
+
+	err := cont.Freeze()
+	if !errors.Is(err, godi.ErrFactoryNotRegistered) {
+		t.Fatalf("Expecting factory not registered, got: %v", err)
+	}
+
+	for _, missing := range []string{"test.Repository", "*test.SomeStruct", `*test.SomeStruct named "missing"`} {
+		if !strings.Contains(err.Error(), missing) {
+			t.Fatalf("Error should list %v: %v", missing, err.Error())
+		}
+	}
+
+	if strings.Contains(err.Error(), "test.Doer") {
+		t.Fatalf("Optional dependencies should not be listed: %v", err.Error())
+	}
+
+	// A container failing validation is not frozen
+	err = godi.ProvideAs[Repository](cont, godi.LifetimeSingleton, NewRepositoryImpl)
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err.Error())
+	}
+}
+
+func TestDeclareScoped(t *testing.T) {
+	var cont = godi.New()
+	godi.DeclareScoped[*SomeStruct](cont)
+	godi.ProvideAs[Repository](cont, godi.LifetimeSingleton, NewRepositoryImpl)
+	godi.Provide(cont, godi.LifetimeScoped, NewService)
+
+	if err := cont.Freeze(); err != nil {
+		t.Fatalf("Declared dependencies should be valid: %v", err.Error())
+	}
+
+	scope := cont.NewScope()
+	if _, err := godi.Get[*Service](scope); !errors.Is(err, godi.ErrFactoryNotRegistered) {
+		t.Fatalf("Expecting factory not registered until registered on the scope, got: %v", err)
+	}
+
+	scope = cont.NewScope()
+	err := godi.Scoped(scope, func(c *godi.Container) *SomeStruct {
+		return &SomeStruct{data: "scoped"}
+	})
+	if err != nil {
+		t.Fatalf("Declared definitions should be registered on the scope: %v", err.Error())
+	}
+
+	svc, err := godi.Get[*Service](scope)
+	if err != nil || svc.some.data != "scoped" {
+		t.Fatalf("Failed to get instance: %v", err)
+	}
+}
+
+func TestFreezeErrorOnScope(t *testing.T) {
+	var cont = godi.New()
+
+	if err := cont.NewScope().Freeze(); err != godi.ErrFreezeOnScope {
+		t.Fatalf("Expecting freeze on scope, got: %v", err)
+	}
+}
+
+func BenchmarkGetSingletonOnFrozenContainer(b *testing.B) {
+	var cont = godi.New()
+	godi.ProvideAs[Repository](cont, godi.LifetimeSingleton, NewRepositoryImpl)
+	cont.Freeze()
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			godi.Get[Repository](cont)
+		}
+	})
+}
+
+func BenchmarkNewScopeOnFrozenContainer(b *testing.B) {
+	var cont = godi.New()
+	godi.ProvideAs[Repository](cont, godi.LifetimeSingleton, NewRepositoryImpl)
+	godi.DeclareScoped[*SomeStruct](cont)
+	godi.Provide(cont, godi.LifetimeScoped, NewService)
+	cont.Freeze()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		scope := cont.NewScope()
+		godi.Scoped(scope, func(c *godi.Container) *SomeStruct {
+			return &SomeStruct{}
+		})
+		godi.Get[*Service](scope)
+	}
+}
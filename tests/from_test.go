@@ -0,0 +1,95 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mingue/godi"
+)
+
+func TestFromResolvesParameters(t *testing.T) {
+	var cont = godi.New()
+	godi.SingletonFrom1(cont, func(s *SomeStruct) Repository {
+		return &RepositoryImpl{name: s.data}
+	})
+	godi.Singleton(cont, func(c *godi.Container) *SomeStruct {
+		return &SomeStruct{data: "repo"}
+	})
+	godi.ScopedFrom2(cont, NewService)
+
+	svc, err := godi.Get[*Service](cont)
+	if err != nil {
+		t.Fatalf("Failed to get instance: %v", err.Error())
+	}
+
+	if svc.repo.Name() != "repo" || svc.some == nil {
+		t.Fatalf("Parameters should be resolved from the container")
+	}
+}
+
+func TestFromHonorsLifetime(t *testing.T) {
+	var cont = godi.New()
+	godi.Transient(cont, func(c *godi.Container) Repository {
+		return NewRepositoryImpl()
+	})
+	godi.TransientFrom1(cont, func(r Repository) *Service {
+		return &Service{repo: r}
+	})
+	godi.Instance(cont, "data")
+	godi.SingletonFrom1(cont, func(data string) *SomeStruct {
+		return &SomeStruct{data: data}
+	})
+
+	x, _ := godi.Get[*Service](cont)
+	y, _ := godi.Get[*Service](cont)
+
+	if x == y {
+		t.Fatalf("Transient should return new instances")
+	}
+
+	a, err := godi.Get[*SomeStruct](cont)
+	if err != nil {
+		t.Fatalf("Failed to get instance: %v", err.Error())
+	}
+
+	b, _ := godi.Get[*SomeStruct](cont)
+
+	if a != b || a.data != "data" {
+		t.Fatalf("Singleton should return the same instance")
+	}
+}
+
+func TestFromWithMaxArity(t *testing.T) {
+	var cont = godi.New()
+	godi.Transient(cont, func(c *godi.Container) int { return 1 })
+	godi.Transient(cont, func(c *godi.Container) int8 { return 2 })
+	godi.Transient(cont, func(c *godi.Container) int16 { return 3 })
+	godi.Transient(cont, func(c *godi.Container) int32 { return 4 })
+	godi.Transient(cont, func(c *godi.Container) int64 { return 5 })
+	godi.Transient(cont, func(c *godi.Container) uint { return 6 })
+	godi.TransientFrom6(cont, func(a int, b int8, c int16, d int32, e int64, f uint) float64 {
+		return float64(a) + float64(b) + float64(c) + float64(d) + float64(e) + float64(f)
+	})
+
+	sum, err := godi.Get[float64](cont)
+	if err != nil {
+		t.Fatalf("Failed to get instance: %v", err.Error())
+	}
+
+	if sum != 21 {
+		t.Fatalf("All parameters should be resolved, got: %v", sum)
+	}
+}
+
+func TestFromReturnsErrorIfParameterNotRegistered(t *testing.T) {
+	var cont = godi.New()
+	godi.Transient(cont, func(c *godi.Container) Repository {
+		return NewRepositoryImpl()
+	})
+	godi.TransientFrom2(cont, NewService)
+
+	_, err := godi.Get[*Service](cont)
+	if !errors.Is(err, godi.ErrFactoryNotRegistered) {
+		t.Fatalf("Expecting factory not registered, got: %v", err)
+	}
+}
@@ -0,0 +1,190 @@
+package test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mingue/godi"
+)
+
+type (
+	InjectedHandler struct {
+		Repo      Repository      `godi:""`
+		Some      *SomeStruct     `godi:"name=some"`
+		Optional  Doer            `godi:"optional"`
+		Container *godi.Container `godi:""`
+		NotTagged Repository
+	}
+	MissingFieldsHandler struct {
+		Repo Repository  `godi:""`
+		Some *SomeStruct `godi:"name=missing"`
+		Doer Doer        `godi:"optional"`
+	}
+	UnexportedFieldsHandler struct {
+		Repo    Repository `godi:""`
+		private Repository `godi:""`
+	}
+)
+
+func (h *InjectedHandler) Do() {}
+
+func TestInjectFillsTaggedFields(t *testing.T) {
+	var cont = godi.New()
+	godi.Singleton(cont, func(c *godi.Container) Repository {
+		return NewRepositoryImpl()
+	})
+	godi.TransientNamed(cont, "some", func(c *godi.Container) *SomeStruct {
+		return &SomeStruct{data: "some"}
+	})
+
+	var h InjectedHandler
+	err := godi.Inject(cont, &h)
+	if err != nil {
+		t.Fatalf("Failed to inject: %v", err.Error())
+	}
+
+	if h.Repo == nil || h.Some.data != "some" || h.Container != cont {
+		t.Fatalf("Tagged fields should be injected")
+	}
+
+	if h.Optional != nil || h.NotTagged != nil {
+		t.Fatalf("Optional and not tagged fields should be left empty")
+	}
+}
+
+func TestInjectReportsEveryUnresolvedField(t *testing.T) {
+	var cont = godi.New()
+
+	var h MissingFieldsHandler
+	err := godi.Inject(cont, &h)
+	if !errors.Is(err, godi.ErrUnresolvedFields) || !errors.Is(err, godi.ErrFactoryNotRegistered) {
+		t.Fatalf("Expecting unresolved fields, got: %v", err)
+	}
+
+	if !strings.Contains(err.Error(), "Repo") || !strings.Contains(err.Error(), "Some") {
+		t.Fatalf("All required fields should be listed: %v", err.Error())
+	}
+
+	if strings.Contains(err.Error(), "Doer") {
+		t.Fatalf("Optional fields should not be listed: %v", err.Error())
+	}
+}
+
+func TestInjectRejectsUnexportedFields(t *testing.T) {
+	var cont = godi.New()
+	godi.Singleton(cont, func(c *godi.Container) Repository {
+		return NewRepositoryImpl()
+	})
+
+	var h UnexportedFieldsHandler
+	err := godi.Inject(cont, &h)
+	if !errors.Is(err, godi.ErrUnexportedField) || !strings.Contains(err.Error(), "private") {
+		t.Fatalf("Expecting unexported field error, got: %v", err)
+	}
+
+	if h.private != nil {
+		t.Fatalf("Unexported fields should not be set")
+	}
+
+	err = godi.AutoWire[*UnexportedFieldsHandler](cont, godi.LifetimeTransient)
+	if !errors.Is(err, godi.ErrUnexportedField) {
+		t.Fatalf("Expecting unexported field error on registration, got: %v", err)
+	}
+}
+
+func TestInjectRejectsInvalidTargets(t *testing.T) {
+	var cont = godi.New()
+	var h InjectedHandler
+
+	for _, target := range []any{nil, h, new(int)} {
+		if err := godi.Inject(cont, target); err != godi.ErrInvalidInjectTarget {
+			t.Fatalf("Expecting invalid target for %T, got: %v", target, err)
+		}
+	}
+}
+
+func TestAutoWire(t *testing.T) {
+	var cont = godi.New()
+	godi.Singleton(cont, func(c *godi.Container) Repository {
+		return NewRepositoryImpl()
+	})
+	godi.TransientNamed(cont, "some", func(c *godi.Container) *SomeStruct {
+		return &SomeStruct{data: "some"}
+	})
+	godi.AutoWire[*InjectedHandler](cont, godi.LifetimeScoped)
+	godi.AutoWireNamed[InjectedHandler](cont, "value", godi.LifetimeTransient)
+
+	x, err := godi.Get[*InjectedHandler](cont)
+	if err != nil {
+		t.Fatalf("Failed to get instance: %v", err.Error())
+	}
+
+	y, _ := godi.Get[*InjectedHandler](cont)
+	if x != y || x.Repo == nil {
+		t.Fatalf("It should build the struct honoring its lifetime")
+	}
+
+	z, err := godi.GetNamed[InjectedHandler](cont, "value")
+	if err != nil || z.Some == nil {
+		t.Fatalf("It should build struct values: %v", err)
+	}
+}
+
+func TestAutoWireReturnsUnresolvedFields(t *testing.T) {
+	var cont = godi.New()
+	godi.AutoWire[*MissingFieldsHandler](cont, godi.LifetimeTransient)
+
+	_, err := godi.Get[*MissingFieldsHandler](cont)
+	if !errors.Is(err, godi.ErrUnresolvedFields) {
+		t.Fatalf("Expecting unresolved fields, got: %v", err)
+	}
+
+	if err := godi.AutoWire[Repository](cont, godi.LifetimeTransient); err != godi.ErrInvalidInjectTarget {
+		t.Fatalf("Expecting invalid target, got: %v", err)
+	}
+
+	if err := godi.AutoWireNamed[*MissingFieldsHandler](cont, "typo", "Scope"); !errors.Is(err, godi.ErrInvalidLifetime) {
+		t.Fatalf("Expecting invalid lifetime, got: %v", err)
+	}
+}
+
+func TestAutoWireAs(t *testing.T) {
+	var cont = godi.New()
+	godi.Singleton(cont, func(c *godi.Container) Repository {
+		return NewRepositoryImpl()
+	})
+	godi.TransientNamed(cont, "some", func(c *godi.Container) *SomeStruct {
+		return &SomeStruct{data: "some"}
+	})
+	godi.AutoWireNamedAs[Doer, *InjectedHandler](cont, "handler", godi.LifetimeScoped)
+
+	x, err := godi.GetNamed[Doer](cont, "handler")
+	if err != nil {
+		t.Fatalf("Failed to get instance: %v", err.Error())
+	}
+
+	h, ok := x.(*InjectedHandler)
+	if !ok || h.Repo == nil || h.Some == nil {
+		t.Fatalf("It should build the struct for the interface")
+	}
+
+	if err := cont.Validate(); err != nil {
+		t.Fatalf("Dependencies of the struct should be valid: %v", err)
+	}
+
+	err = godi.AutoWireAs[Doer, *MissingFieldsHandler](cont, godi.LifetimeTransient)
+	if !errors.Is(err, godi.ErrInvalidInjectTarget) {
+		t.Fatalf("Expecting not assignable error, got: %v", err)
+	}
+}
+
+func TestAutoWireAsRecordsDependencies(t *testing.T) {
+	var cont = godi.New()
+	godi.AutoWireAs[Doer, *InjectedHandler](cont, godi.LifetimeTransient)
+
+	err := cont.Validate()
+	if !errors.Is(err, godi.ErrFactoryNotRegistered) || !strings.Contains(err.Error(), "Repository") {
+		t.Fatalf("Expecting the missing dependencies of the struct, got: %v", err)
+	}
+}
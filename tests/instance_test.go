@@ -0,0 +1,136 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mingue/godi"
+)
+
+func TestGetInstance(t *testing.T) {
+	var cont = godi.New()
+	x := &SomeStruct{data: "x"}
+	godi.Instance(cont, x)
+
+	y, err := godi.Get[*SomeStruct](cont)
+	if err != nil {
+		t.Fatalf("Failed to get instance: %v", err.Error())
+	}
+
+	if x != y {
+		t.Fatalf("It should return the registered instance")
+	}
+
+	z, _ := godi.Get[*SomeStruct](cont.NewScope())
+	if x != z {
+		t.Fatalf("It should return the registered instance on new scopes")
+	}
+}
+
+func TestGetNamedInstance(t *testing.T) {
+	var cont = godi.New()
+	godi.InstanceNamed[SomeInterface](cont, "1", &SomeStruct{data: "1"})
+	godi.InstanceNamed[SomeInterface](cont, "2", &SomeStruct{data: "2"})
+
+	x, _ := godi.GetNamed[SomeInterface](cont, "2")
+
+	if x.(*SomeStruct).data != "2" {
+		t.Fatalf("It should return the named instance")
+	}
+}
+
+func TestInstanceErrorIfAlreadyRegistered(t *testing.T) {
+	var cont = godi.New()
+	godi.Singleton(cont, func(c *godi.Container) *SomeStruct {
+		return &SomeStruct{}
+	})
+
+	err := godi.Instance(cont, &SomeStruct{})
+	if err != godi.ErrFactoryAlreadyRegistered {
+		t.Fatalf("Expecting factory already registered, got: %v", err)
+	}
+}
+
+func TestInstanceCanBeInjected(t *testing.T) {
+	var cont = godi.New()
+	godi.InstanceNamed[Repository](cont, "", &RepositoryImpl{name: "instance"})
+	godi.Instance(cont, &SomeStruct{})
+	godi.Provide(cont, godi.LifetimeTransient, NewService)
+
+	svc, err := godi.Get[*Service](cont)
+	if err != nil {
+		t.Fatalf("Failed to get instance: %v", err.Error())
+	}
+
+	if svc.repo.Name() != "instance" {
+		t.Fatalf("It should inject the registered instance")
+	}
+}
+
+func TestDecorateAnInstance(t *testing.T) {
+	var cont = godi.New()
+	doer := &SimpleDoer{}
+	godi.Instance[Doer](cont, doer)
+
+	godi.Decorate(cont, func(d Doer, c *godi.Container) Doer {
+		return &CallCountDecorator{d: d}
+	})
+
+	x, _ := godi.Get[Doer](cont)
+	y, _ := godi.Get[Doer](cont)
+
+	decorator, ok := x.(*CallCountDecorator)
+	if !ok {
+		t.Fatalf("Instance should be decorated")
+	}
+
+	if decorator.d != doer {
+		t.Fatalf("Decorator should wrap the registered instance")
+	}
+
+	if x != y {
+		t.Fatalf("Decorated instance should be a singleton")
+	}
+}
+
+func TestDecorateAnInstanceOfAScope(t *testing.T) {
+	var cont = godi.New()
+	scope := cont.NewScope()
+	doer := &SimpleDoer{}
+	godi.Instance[Doer](scope, doer)
+
+	godi.Decorate(cont, decorateWithName("container"))
+
+	x, _ := godi.Get[Doer](scope)
+	if names := strings.Join(decoratorNames(x), ","); names != "container" {
+		t.Fatalf("Instance of the scope should be decorated by the container, got: %v", names)
+	}
+
+	if y, _ := godi.Get[Doer](scope); x != y {
+		t.Fatalf("Decorated instance should be a singleton of the scope")
+	}
+
+	nested := scope.NewScope()
+	godi.InstanceNamed[Doer](nested, "nested", doer)
+
+	godi.Decorate(scope, decorateWithName("scope"))
+
+	z, _ := godi.GetNamed[Doer](nested, "nested")
+	if names := strings.Join(decoratorNames(z), ","); names != "scope,container" {
+		t.Fatalf("Instance of the nested scope should be decorated by the parent scope, got: %v", names)
+	}
+}
+
+func TestInstanceOfAScopeWithDecoratorsRegisteredBefore(t *testing.T) {
+	var cont = godi.New()
+	godi.Decorate(cont, decorateWithName("container"))
+	scope := cont.NewScope()
+	godi.Decorate(scope, decorateWithName("scope"))
+	nested := scope.NewScope()
+	godi.Instance[Doer](nested, &SimpleDoer{})
+
+	x, _ := godi.Get[Doer](nested)
+	if names := strings.Join(decoratorNames(x), ","); names != "scope,container" {
+		t.Fatalf("Instance of the nested scope should be decorated, got: %v", names)
+	}
+}
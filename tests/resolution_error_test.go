@@ -0,0 +1,89 @@
+package test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mingue/godi"
+)
+
+func TestResolutionErrorDescribesMissingRegistration(t *testing.T) {
+	var cont = godi.New()
+	godi.ProvideAs[Repository](cont, godi.LifetimeScoped, NewRepositoryImpl)
+	godi.Provide(cont, godi.LifetimeScoped, NewService)
+	godi.ScopedNamed(cont, "some", func(c *godi.Container) *SomeStruct {
+		return &SomeStruct{}
+	})
+	godi.Scoped(cont, func(c *godi.Container) SomeStruct {
+		return SomeStruct{}
+	})
+
+	_, err := godi.Get[*Service](cont.NewScope().NewScope())
+
+	var resolutionErr *godi.ResolutionError
+	if !errors.As(err, &resolutionErr) {
+		t.Fatalf("Expecting a resolution error, got: %v", err)
+	}
+
+	if !errors.Is(err, godi.ErrFactoryNotRegistered) {
+		t.Fatalf("Expecting factory not registered, got: %v", err)
+	}
+
+	if resolutionErr.Type != reflect.TypeOf(&SomeStruct{}) || resolutionErr.Name != "" || resolutionErr.ScopeDepth != 2 {
+		t.Fatalf("Error should describe the missing registration: %v", err.Error())
+	}
+
+	if len(resolutionErr.Chain) != 1 || resolutionErr.Chain[0] != "*test.Service" {
+		t.Fatalf("Error should contain the dependency chain: %v", resolutionErr.Chain)
+	}
+
+	suggestions := strings.Join(resolutionErr.Suggestions, ",")
+	if suggestions != `*test.SomeStruct named "some",test.SomeStruct` {
+		t.Fatalf("Error should suggest similar registrations, got: %v", suggestions)
+	}
+}
+
+func TestResolutionErrorContainsLifetime(t *testing.T) {
+	var cont = godi.New()
+	godi.Scoped(cont, func(c *godi.Container) *SomeStruct {
+		return &SomeStruct{}
+	})
+	godi.SingletonE(cont, func(c *godi.Container) (Repository, error) {
+		some, err := godi.Get[*SomeStruct](c)
+		if err != nil {
+			return nil, err
+		}
+
+		return &RepositoryImpl{name: some.data}, nil
+	})
+
+	_, err := godi.Get[Repository](cont)
+
+	var resolutionErr *godi.ResolutionError
+	if !errors.As(err, &resolutionErr) || resolutionErr.Lifetime != godi.LifetimeScoped {
+		t.Fatalf("Expecting a resolution error for the scoped registration, got: %v", err)
+	}
+
+	if !errors.Is(err, godi.ErrCaptiveDependency) {
+		t.Fatalf("Expecting captive dependency, got: %v", err)
+	}
+}
+
+func TestResolutionErrorOnSingletonOfClosedScope(t *testing.T) {
+	var cont = godi.New()
+	scope := cont.NewScope()
+	godi.Singleton(scope, func(c *godi.Container) *SomeStruct {
+		return &SomeStruct{}
+	})
+	nested := scope.NewScope()
+	scope.Close()
+
+	_, err := godi.Get[*SomeStruct](nested)
+
+	var resolutionErr *godi.ResolutionError
+	if !errors.As(err, &resolutionErr) || !errors.Is(err, godi.ErrScopeClosed) {
+		t.Fatalf("Expecting a resolution error for the closed scope, got: %v", err)
+	}
+}
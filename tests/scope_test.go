@@ -1,6 +1,7 @@
 package test
 
 import (
+	"errors"
 	"strconv"
 	"testing"
 
@@ -321,3 +322,178 @@ func TestSameDefinitionCanBeRegisteredOncePerScopedContainer(t *testing.T) {
 		t.Fatal("It should allow to register instance on new scope")
 	}
 }
+
+func TestNestedScopeSeesParentScopeRegistrations(t *testing.T) {
+	var cont = godi.New()
+	requestScope := cont.NewScope()
+	godi.Scoped(requestScope, func(c *godi.Container) *SomeStruct {
+		return &SomeStruct{data: "request"}
+	})
+
+	operationScope := requestScope.NewScope()
+	x, err := godi.Get[*SomeStruct](operationScope)
+	if err != nil {
+		t.Fatalf("Failed to get instance: %v", err.Error())
+	}
+
+	if x.data != "request" {
+		t.Fatalf("Parent scope registration should be used")
+	}
+
+	y, _ := godi.Get[*SomeStruct](requestScope)
+	if x == y {
+		t.Fatalf("Scoped instances should be cached per scope")
+	}
+}
+
+func TestNestedScopeCanOverrideParentScopeRegistrations(t *testing.T) {
+	var cont = godi.New()
+	requestScope := cont.NewScope()
+	godi.ScopedNamed(requestScope, "1", func(c *godi.Container) *SomeStruct {
+		return &SomeStruct{data: "request"}
+	})
+	godi.ScopedNamed(requestScope, "2", func(c *godi.Container) *SomeStruct {
+		return &SomeStruct{data: "request"}
+	})
+
+	operationScope := requestScope.NewScope()
+	err := godi.ScopedNamed(operationScope, "1", func(c *godi.Container) *SomeStruct {
+		return &SomeStruct{data: "operation"}
+	})
+	if err != nil {
+		t.Fatalf("It should allow to override the parent scope registration: %v", err.Error())
+	}
+
+	x, _ := godi.GetNamed[*SomeStruct](operationScope, "1")
+	y, _ := godi.GetNamed[*SomeStruct](requestScope, "1")
+
+	if x.data != "operation" || y.data != "request" {
+		t.Fatalf("Override should only apply to the nested scope")
+	}
+
+	all, _ := godi.GetAll[*SomeStruct](operationScope)
+	if len(all) != 2 || all[0].data != "operation" || all[1].data != "request" {
+		t.Fatalf("Overridden registrations should only be returned once")
+	}
+}
+
+func TestNestedScopeInheritsParentScopeInstance(t *testing.T) {
+	var cont = godi.New()
+	godi.Scoped(cont, func(c *godi.Container) *SomeStruct {
+		return &SomeStruct{}
+	})
+	godi.Provide(cont, godi.LifetimeScoped, NewService)
+	godi.ProvideAs[Repository](cont, godi.LifetimeSingleton, NewRepositoryImpl)
+	cont.Freeze()
+
+	requestScope := cont.NewScope()
+	operationScope := requestScope.NewScope()
+
+	err := godi.Inherit[*SomeStruct](operationScope)
+	if err != nil {
+		t.Fatalf("Failed to inherit: %v", err.Error())
+	}
+
+	x, _ := godi.Get[*SomeStruct](requestScope)
+	svc, err := godi.Get[*Service](operationScope)
+	if err != nil {
+		t.Fatalf("Failed to get instance: %v", err.Error())
+	}
+
+	if svc.some != x {
+		t.Fatalf("Inherited instance should be the one of the parent scope")
+	}
+
+	parentSvc, _ := godi.Get[*Service](requestScope)
+	if parentSvc == svc {
+		t.Fatalf("Not inherited instances should be cached per scope")
+	}
+}
+
+func TestInheritErrorOnScopeWithoutParentScope(t *testing.T) {
+	var cont = godi.New()
+
+	if err := godi.Inherit[*SomeStruct](cont.NewScope()); err != godi.ErrNoParentScope {
+		t.Fatalf("Expecting no parent scope, got: %v", err)
+	}
+}
+
+func TestScopeRegistrationsOfAnyLifetimeStayOnTheScope(t *testing.T) {
+	var cont = godi.New()
+
+	for i := 0; i < 2; i++ {
+		scope := cont.NewScope()
+
+		err := godi.Transient(scope, func(c *godi.Container) *SomeStruct {
+			return &SomeStruct{data: "transient"}
+		})
+		if err != nil {
+			t.Fatalf("Every scope should be able to register: %v", err.Error())
+		}
+
+		err = godi.Instance(scope, "instance")
+		if err != nil {
+			t.Fatalf("Every scope should be able to register: %v", err.Error())
+		}
+
+		x, err := godi.Get[*SomeStruct](scope)
+		if err != nil || x.data != "transient" {
+			t.Fatalf("Failed to get instance: %v", err)
+		}
+	}
+
+	if _, err := godi.Get[*SomeStruct](cont); !errors.Is(err, godi.ErrFactoryNotRegistered) {
+		t.Fatalf("Scope registrations should not leak to the container, got: %v", err)
+	}
+
+	if _, err := godi.Get[string](cont.NewScope()); !errors.Is(err, godi.ErrFactoryNotRegistered) {
+		t.Fatalf("Scope registrations should not leak to other scopes, got: %v", err)
+	}
+}
+
+func TestScopeSingletonIsSharedWithNestedScopes(t *testing.T) {
+	var cont = godi.New()
+	var closed []string
+	godi.Scoped(cont, func(c *godi.Container) *SomeStruct {
+		return &SomeStruct{}
+	})
+
+	scope := cont.NewScope()
+	godi.Singleton(scope, func(c *godi.Container) *Closable {
+		godi.Get[*SomeStruct](c)
+		return &Closable{name: "scope singleton", closed: &closed}
+	})
+
+	x, err := godi.Get[*Closable](scope)
+	if err != nil {
+		t.Fatalf("Scope singletons can depend on scoped registrations: %v", err.Error())
+	}
+
+	y, _ := godi.Get[*Closable](scope.NewScope())
+	if x != y {
+		t.Fatalf("Scope singletons should be shared with nested scopes")
+	}
+
+	scope.Close()
+
+	if len(closed) != 1 {
+		t.Fatalf("Scope singletons should be disposed with their scope")
+	}
+}
+
+func TestCaptiveScopeSingletonOnSingleton(t *testing.T) {
+	var cont = godi.New()
+	godi.SingletonFrom1(cont, func(s *SomeStruct) *Service {
+		return &Service{some: s}
+	})
+
+	scope := cont.NewScope()
+	godi.Singleton(scope, func(c *godi.Container) *SomeStruct {
+		return &SomeStruct{}
+	})
+
+	_, err := godi.Get[*Service](scope)
+	if !errors.Is(err, godi.ErrCaptiveDependency) {
+		t.Fatalf("Expecting captive dependency, got: %v", err)
+	}
+}
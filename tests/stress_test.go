@@ -0,0 +1,228 @@
+package test
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mingue/godi"
+)
+
+const (
+	stressGoroutines = 500
+	stressIterations = 20
+)
+
+// Runs f from many goroutines released at the same time, to maximize contention on first resolution
+func hammer(t *testing.T, f func(t *testing.T, goroutine int)) {
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+
+	for i := 0; i < stressGoroutines; i++ {
+		wg.Add(1)
+
+		go func(goroutine int) {
+			defer wg.Done()
+			<-start
+
+			for j := 0; j < stressIterations; j++ {
+				f(t, goroutine)
+			}
+		}(i)
+	}
+
+	close(start)
+	wg.Wait()
+}
+
+func TestStressSingletonIsBuiltExactlyOnce(t *testing.T) {
+	var cont = godi.New()
+	var calls atomic.Int32
+	var first atomic.Pointer[SomeStruct]
+	godi.Singleton(cont, func(c *godi.Container) *SomeStruct {
+		calls.Add(1)
+		return &SomeStruct{}
+	})
+
+	hammer(t, func(t *testing.T, goroutine int) {
+		var x *SomeStruct
+		var err error
+
+		if goroutine%2 == 0 {
+			x, err = godi.Get[*SomeStruct](cont)
+		} else {
+			err = godi.GetNoAlloc(cont.NewScope(), &x)
+		}
+
+		first.CompareAndSwap(nil, x)
+
+		if err != nil || x == nil || x != first.Load() {
+			t.Errorf("Expecting the singleton instance, got: %v", err)
+		}
+	})
+
+	if calls.Load() != 1 {
+		t.Fatalf("Singleton should be built exactly once, built: %v", calls.Load())
+	}
+}
+
+func TestStressNamedSingletonsAreBuiltExactlyOnce(t *testing.T) {
+	var cont = godi.New()
+	names := []string{"a", "b", "c", "d"}
+	calls := make([]atomic.Int32, len(names))
+
+	for i, name := range names {
+		i := i
+		godi.SingletonNamed(cont, name, func(c *godi.Container) *SomeStruct {
+			calls[i].Add(1)
+			return &SomeStruct{}
+		})
+	}
+
+	hammer(t, func(t *testing.T, goroutine int) {
+		name := names[goroutine%len(names)]
+
+		if _, err := godi.GetNamed[*SomeStruct](cont, name); err != nil {
+			t.Errorf("Failed to get instance: %v", err)
+		}
+	})
+
+	for i := range names {
+		if calls[i].Load() != 1 {
+			t.Fatalf("Singleton %v should be built exactly once, built: %v", names[i], calls[i].Load())
+		}
+	}
+}
+
+func TestStressScopedIsBuiltOncePerScope(t *testing.T) {
+	var cont = godi.New()
+	var calls atomic.Int32
+	godi.Singleton(cont, func(c *godi.Container) Repository {
+		return NewRepositoryImpl()
+	})
+	godi.Scoped(cont, func(c *godi.Container) *SomeStruct {
+		calls.Add(1)
+		return &SomeStruct{}
+	})
+	godi.Provide(cont, godi.LifetimeScoped, NewService)
+
+	shared := cont.NewScope()
+
+	hammer(t, func(t *testing.T, goroutine int) {
+		scope := cont.NewScope()
+
+		x, err := godi.Get[*Service](scope)
+		if err != nil {
+			t.Errorf("Failed to get instance: %v", err)
+			return
+		}
+
+		var y *SomeStruct
+		godi.GetNoAlloc(scope, &y)
+
+		if x.some != y {
+			t.Errorf("Scoped should return the same instance on the same scope")
+		}
+
+		godi.Get[*SomeStruct](shared)
+	})
+
+	// One per scope created by hammer plus one for the shared scope
+	if calls.Load() != stressGoroutines*stressIterations+1 {
+		t.Fatalf("Scoped should be built once per scope, built: %v", calls.Load())
+	}
+}
+
+func TestStressTransientAndDecoratedInstances(t *testing.T) {
+	var cont = godi.New()
+	godi.Transient(cont, func(c *godi.Container) Doer {
+		return &SimpleDoer{}
+	})
+	godi.SingletonNamed(cont, "singleton", func(c *godi.Container) Doer {
+		return &SimpleDoer{}
+	})
+	godi.Decorate(cont, func(d Doer, c *godi.Container) Doer {
+		return &CallCountDecorator{d: d}
+	})
+
+	hammer(t, func(t *testing.T, goroutine int) {
+		all, err := godi.GetAll[Doer](cont.NewScope())
+		if err != nil || len(all) != 2 {
+			t.Errorf("Failed to get instances: %v", err)
+			return
+		}
+
+		for _, doer := range all {
+			if _, ok := doer.(*CallCountDecorator); !ok {
+				t.Errorf("Every instance should be decorated")
+			}
+		}
+	})
+}
+
+func TestStressRegistrationWhileResolving(t *testing.T) {
+	var cont = godi.New()
+	godi.Singleton(cont, func(c *godi.Container) Repository {
+		return NewRepositoryImpl()
+	})
+	godi.Transient(cont, func(c *godi.Container) Doer {
+		return &SimpleDoer{}
+	})
+
+	hammer(t, func(t *testing.T, goroutine int) {
+		switch goroutine % 4 {
+		case 0:
+			godi.TransientNamed(cont, strconv.Itoa(goroutine), func(c *godi.Container) *SomeStruct {
+				return &SomeStruct{}
+			})
+		case 1:
+			scope := cont.NewScope()
+			godi.Scoped(scope, func(c *godi.Container) *SomeStruct {
+				return &SomeStruct{}
+			})
+
+			if _, err := godi.Get[*SomeStruct](scope); err != nil {
+				t.Errorf("Failed to get scoped instance: %v", err)
+			}
+		case 2:
+			godi.Decorate(cont, func(d Doer, c *godi.Container) Doer {
+				return d
+			})
+		default:
+			if _, err := godi.Get[Repository](cont); err != nil {
+				t.Errorf("Failed to get instance: %v", err)
+			}
+
+			if _, err := godi.Get[Doer](cont); err != nil {
+				t.Errorf("Failed to get instance: %v", err)
+			}
+
+			godi.GetAll[*SomeStruct](cont)
+		}
+	})
+
+	all, _ := godi.GetAll[*SomeStruct](cont)
+	if len(all) != stressGoroutines/4 {
+		t.Fatalf("Every named registration should be registered once, got: %v", len(all))
+	}
+}
+
+func TestStressSameRegistrationIsOnlyAcceptedOnce(t *testing.T) {
+	var cont = godi.New()
+	var registered atomic.Int32
+
+	hammer(t, func(t *testing.T, goroutine int) {
+		err := godi.Singleton(cont, func(c *godi.Container) *SomeStruct {
+			return &SomeStruct{}
+		})
+
+		if err == nil {
+			registered.Add(1)
+		}
+	})
+
+	if registered.Load() != 1 {
+		t.Fatalf("Registration should only be accepted once, accepted: %v", registered.Load())
+	}
+}
@@ -0,0 +1,72 @@
+package test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mingue/godi"
+)
+
+func TestValidateReportsAllProblems(t *testing.T) {
+	var cont, other = godi.New(), godi.New()
+
+	// Same registrations on both containers, to check the errors are reported in the same order
+	for _, c := range []*godi.Container{cont, other} {
+		godi.Provide(c, godi.LifetimeScoped, NewService)
+		godi.TransientFrom1(c, func(s *Service) Repository {
+			return &RepositoryImpl{}
+		})
+		godi.SingletonFrom1(c, func(r Repository) Doer {
+			return &SimpleDoer{}
+		})
+	}
+
+	err := cont.Validate()
+
+	for _, expected := range []error{godi.ErrFactoryNotRegistered, godi.ErrCircularDependency, godi.ErrCaptiveDependency} {
+		if !errors.Is(err, expected) {
+			t.Fatalf("Expecting %v, got: %v", expected, err)
+		}
+	}
+
+	expected := []string{
+		"*test.Service depends on *test.SomeStruct",
+		"*test.Service -> test.Repository -> *test.Service",
+		"Singleton test.Doer depends on Transient test.Repository",
+	}
+
+	for _, problem := range expected {
+		if !strings.Contains(err.Error(), problem) {
+			t.Fatalf("Error should contain %v: %v", problem, err.Error())
+		}
+	}
+
+	if other.Validate().Error() != err.Error() {
+		t.Fatalf("Errors should be reported in the same order")
+	}
+
+	if freezeErr := cont.Freeze(); freezeErr == nil || freezeErr.Error() != err.Error() {
+		t.Fatalf("Freeze should validate the registrations, got: %v", freezeErr)
+	}
+}
+
+func TestValidateScope(t *testing.T) {
+	var cont = godi.New()
+	godi.DeclareScoped[*SomeStruct](cont)
+	godi.ProvideAs[Repository](cont, godi.LifetimeSingleton, NewRepositoryImpl)
+	godi.Provide(cont, godi.LifetimeScoped, NewService)
+
+	if err := cont.Validate(); err != nil {
+		t.Fatalf("Failed to validate: %v", err.Error())
+	}
+
+	scope := cont.NewScope()
+	godi.ScopedFrom1(scope, func(d Doer) *SomeStruct {
+		return &SomeStruct{}
+	})
+
+	if err := scope.Validate(); !errors.Is(err, godi.ErrFactoryNotRegistered) || !strings.Contains(err.Error(), "test.Doer") {
+		t.Fatalf("Expecting factory not registered for the scope registration, got: %v", err)
+	}
+}
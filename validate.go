@@ -0,0 +1,176 @@
+package godi
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Validate checks the dependencies declared by constructors, From functions and AutoWire
+// of the registrations visible from the container, reporting all the problems at once:
+// dependencies not registered, circular dependencies and captive dependencies.
+// Registrations done with plain factories can't be checked, as their dependencies are unknown.
+func (c *Container) Validate() error {
+	c.globalDef.mx.RLock()
+	defs := snapshot(nil, c.globalDef)
+	c.globalDef.mx.RUnlock()
+
+	// The closest scope wins, so the scopes are added from the outermost one
+	var scopes []*registry
+
+	for scope := c; scope != nil && scope.scopedDef != nil; scope = scope.parent {
+		scopes = append(scopes, scope.scopedDef)
+	}
+
+	for i := len(scopes) - 1; i >= 0; i-- {
+		scopes[i].mx.RLock()
+		defs = snapshot(defs, scopes[i])
+		scopes[i].mx.RUnlock()
+	}
+
+	return validate(c, defs)
+}
+
+// Adds the definitions of the registry to defs, needs to be called holding the lock of the registry
+func snapshot(defs map[cacheKey]*definition, r *registry) map[cacheKey]*definition {
+	if defs == nil {
+		defs = make(map[cacheKey]*definition)
+	}
+
+	for key, typeDef := range r.defs {
+		for name, d := range typeDef {
+			defs[cacheKey{key: key, name: name}] = d
+		}
+	}
+
+	return defs
+}
+
+func validate(c *Container, defs map[cacheKey]*definition) error {
+	keys := make([]cacheKey, 0, len(defs))
+
+	for k := range defs {
+		keys = append(keys, k)
+	}
+
+	// Sorted so the errors are reported in the same order on every run
+	sort.Slice(keys, func(i, j int) bool {
+		return describe(keys[i].key, keys[i].name) < describe(keys[j].key, keys[j].name)
+	})
+
+	v := &validation{
+		defs:  defs,
+		state: make(map[cacheKey]visitState, len(defs)),
+	}
+
+	checkCaptive := CaptiveDependencyMode(c.shared.captiveMode.Load()) != CaptiveDependencyAllow
+
+	for _, k := range keys {
+		v.checkCycles(k)
+
+		if checkCaptive {
+			v.checkCaptive(k)
+		}
+
+		v.checkMissing(k)
+	}
+
+	sort.Slice(v.errs, func(i, j int) bool {
+		return v.errs[i].Error() < v.errs[j].Error()
+	})
+
+	return errors.Join(v.errs...)
+}
+
+type visitState int
+
+const (
+	notVisited visitState = iota
+	visiting
+	visited
+)
+
+type validation struct {
+	defs  map[cacheKey]*definition
+	state map[cacheKey]visitState
+	// Registrations being visited, to report the path of a cycle
+	path []cacheKey
+	errs []error
+}
+
+func (v *validation) checkMissing(k cacheKey) {
+	for _, dep := range v.defs[k].deps {
+		if _, found := v.defs[cacheKey{key: dep.key, name: dep.name}]; found || dep.optional {
+			continue
+		}
+
+		v.errs = append(v.errs, fmt.Errorf("%v depends on %v: %w",
+			describe(k.key, k.name), describe(dep.key, dep.name), ErrFactoryNotRegistered))
+	}
+}
+
+// A singleton of the container captures its dependencies, so they need to be singletons of the container,
+// dependencies of dependencies are checked when visiting them
+func (v *validation) checkCaptive(k cacheKey) {
+	d := v.defs[k]
+
+	if d.lifetime != LifetimeSingleton || d.owner != nil {
+		return
+	}
+
+	for _, dep := range d.deps {
+		depDef, found := v.defs[cacheKey{key: dep.key, name: dep.name}]
+		if !found || (depDef.lifetime == LifetimeSingleton && depDef.owner == nil) {
+			continue
+		}
+
+		v.errs = append(v.errs, fmt.Errorf("%w: %v %v depends on %v %v",
+			ErrCaptiveDependency, d.lifetime, describe(k.key, k.name), describeLifetime(depDef), describe(dep.key, dep.name)))
+	}
+}
+
+// Depth first search through the dependencies, reaching a registration being visited is a cycle
+func (v *validation) checkCycles(k cacheKey) {
+	switch v.state[k] {
+	case visited:
+		return
+	case visiting:
+		v.errs = append(v.errs, fmt.Errorf("%w: %v", ErrCircularDependency, v.cycle(k)))
+
+		return
+	}
+
+	v.state[k] = visiting
+	v.path = append(v.path, k)
+
+	for _, dep := range v.defs[k].deps {
+		depKey := cacheKey{key: dep.key, name: dep.name}
+
+		if _, found := v.defs[depKey]; found {
+			v.checkCycles(depKey)
+		}
+	}
+
+	v.path = v.path[:len(v.path)-1]
+	v.state[k] = visited
+}
+
+// Describes the cycle from the first time the registration was visited, like A -> B -> A
+func (v *validation) cycle(k cacheKey) string {
+	var steps []string
+
+	for i := len(v.path) - 1; i >= 0; i-- {
+		steps = append(steps, describe(v.path[i].key, v.path[i].name))
+
+		if v.path[i] == k {
+			break
+		}
+	}
+
+	for i, j := 0, len(steps)-1; i < j; i, j = i+1, j-1 {
+		steps[i], steps[j] = steps[j], steps[i]
+	}
+
+	return strings.Join(append(steps, describe(k.key, k.name)), " -> ")
+}